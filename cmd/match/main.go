@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/n7down/ssh-chess/internal/engine"
+	"github.com/n7down/ssh-chess/internal/game"
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/n7down/ssh-chess/internal/match"
+	"github.com/n7down/ssh-chess/internal/uci"
+)
+
+// newEngine returns the engine for a spec, builtin:<level> is the built-in
+// engine and uci:<path>[:<level>] is a uci engine such as stockfish
+func newEngine(spec string, config uci.Config, logger logger.Logger) (match.Engine, *uci.Pool, error) {
+	kind, value := spec, ""
+	if i := strings.Index(spec, ":"); i >= 0 {
+		kind, value = spec[:i], spec[i+1:]
+	}
+
+	switch kind {
+	case "builtin":
+		level := engine.MaxLevel
+		if value != "" {
+			var err error
+			if level, err = strconv.Atoi(value); err != nil || level < engine.MinLevel || level > engine.MaxLevel {
+				return match.Engine{}, nil, fmt.Errorf("the built-in engine's level must be between %d and %d: %s", engine.MinLevel, engine.MaxLevel, value)
+			}
+		}
+		return match.Engine{
+			Name: fmt.Sprintf("builtin level %d", level),
+			NewBot: func() game.Bot {
+				return engine.NewEngine(level)
+			},
+		}, nil, nil
+	case "uci":
+		path, level := value, engine.MaxLevel
+		if i := strings.LastIndex(value, ":"); i >= 0 {
+			if l, err := strconv.Atoi(value[i+1:]); err == nil {
+				path, level = value[:i], l
+			}
+		}
+		if path == "" {
+			return match.Engine{}, nil, fmt.Errorf("a uci engine needs a path: %s", spec)
+		}
+		config.Path = path
+		pool := uci.NewPool(config, logger)
+		return match.Engine{
+			Name: fmt.Sprintf("%s level %d", path, level),
+			NewBot: func() game.Bot {
+				return pool.Bot(level)
+			},
+		}, pool, nil
+	}
+	return match.Engine{}, nil, fmt.Errorf("unknown engine: %s, use builtin:<level> or uci:<path>[:<level>]", spec)
+}
+
+// readOpenings returns the FENs in the file, one on each line
+func readOpenings(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	openings := []string{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+			openings = append(openings, line)
+		}
+	}
+	return openings, scanner.Err()
+}
+
+func main() {
+	games := flag.Int("games", 10, "number of games to play")
+	first := flag.String("engine1", "builtin:5", "first engine, builtin:<level> or uci:<path>[:<level>]")
+	second := flag.String("engine2", "builtin:3", "second engine, builtin:<level> or uci:<path>[:<level>]")
+	timeControl := flag.String("tc", "", "time control of each game, for example 5+3, games have no clock without one")
+	concurrency := flag.Int("concurrency", 1, "number of games played at once")
+	openingsFile := flag.String("openings", "", "file of FENs the games start from, one on each line")
+	pgnFile := flag.String("pgn", "match.pgn", "file the PGNs of the games are written to")
+	moveTime := flag.Duration("movetime", 0, "time uci engines think about each move, 1s when neither it nor the depth is given")
+	depth := flag.Int("depth", 0, "depth uci engines search each move to, the search stops at the move time too when both are given")
+	timeout := flag.Duration("timeout", 0, "time uci engines have to answer with a move, set from the move time or depth by default")
+	flag.Parse()
+
+	logger := blanklogger.NewBlankLogger()
+
+	config := match.Config{
+		Games:       *games,
+		Concurrency: *concurrency,
+		Options:     game.RoomOptions{Name: "match"},
+	}
+
+	if *timeControl != "" {
+		tc, err := game.ParseTimeControl(*timeControl)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		config.Options.TimeControl = &tc
+	}
+
+	if *openingsFile != "" {
+		openings, err := readOpenings(*openingsFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		config.Openings = openings
+	}
+
+	uciConfig := uci.Config{MoveTime: *moveTime, Depth: *depth, Timeout: *timeout, PoolSize: *concurrency}
+	for i, spec := range []string{*first, *second} {
+		e, pool, err := newEngine(spec, uciConfig, logger)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		if pool != nil {
+			defer pool.Close()
+		}
+		config.Engines[i] = e
+	}
+
+	pgn, err := os.Create(*pgnFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer pgn.Close()
+
+	fmt.Printf("%s vs %s, %d games\n", config.Engines[0].Name, config.Engines[1].Name, config.Games)
+	summary, err := match.Run(config, logger, func(result match.Result) {
+		if result.Err != nil {
+			fmt.Printf("round %d: %v\n", result.Round, result.Err)
+			return
+		}
+		fmt.Fprintf(pgn, "%s\n\n", result.PGN)
+		fmt.Printf("round %d: %s by %s\n", result.Round, result.Outcome, result.Method)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	fmt.Printf("%s vs %s: %s\n", config.Engines[0].Name, config.Engines[1].Name, summary)
+}
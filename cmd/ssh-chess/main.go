@@ -1,18 +1,35 @@
 package main
 
 import (
+	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	_ "github.com/lib/pq"
+	"github.com/n7down/ssh-chess/internal/auth"
+	"github.com/n7down/ssh-chess/internal/engine"
 	"github.com/n7down/ssh-chess/internal/game"
 	"github.com/n7down/ssh-chess/internal/logger"
 	"github.com/n7down/ssh-chess/internal/logger/logruslogger"
+	"github.com/n7down/ssh-chess/internal/puzzle"
+	"github.com/n7down/ssh-chess/internal/uci"
+	"github.com/n7down/ssh-chess/internal/utils"
+	"github.com/notnil/chess"
 	"golang.org/x/crypto/ssh"
 )
 
-func handler(conn net.Conn, gm *game.GameManager, config *ssh.ServerConfig, logger logger.Logger) {
+// Version and Build are set by the Makefile's ldflags
+var (
+	Version string
+	Build   string
+)
+
+func handler(conn net.Conn, gm *game.GameManager, config *ssh.ServerConfig, keys *auth.KeyStore, logger logger.Logger) {
 	// Before use, a handshake must be performed on the incoming
 	// net.Conn.
 	sshConn, chans, reqs, err := ssh.NewServerConn(conn, config)
@@ -22,6 +39,13 @@ func handler(conn net.Conn, gm *game.GameManager, config *ssh.ServerConfig, logg
 		return
 	}
 
+	// the key the player authenticated with claims the player's name
+	if err := auth.ClaimName(keys, sshConn.Permissions); err != nil {
+		logger.Debug(fmt.Sprintf("failed to claim the player's name: %v", err.Error()))
+		sshConn.Close()
+		return
+	}
+
 	// The incoming Request channel must be serviced.
 	go ssh.DiscardRequests(reqs)
 
@@ -42,48 +66,152 @@ func handler(conn net.Conn, gm *game.GameManager, config *ssh.ServerConfig, logg
 			return
 		}
 
-		// FIXME: pull out the requests
-		// find the exec request and get the payload
-		// put it back into the requests and pass it to the go func
-
 		// TODO: Remove this -- only temporary while we launch on HN
 		//
 		// To see how many concurrent users are online
 		//fmt.Printf("Player joined. Current stats: %d users, %d games\n",
 		//gm.SessionCount(), gm.GameCount())
 
-		// Reject all out of band requests accept for the unix defaults, pty-req and
-		// shell.
+		// Reject all out of band requests accept for the unix defaults, pty-req,
+		// window-change, shell and exec. The player joins a game once the shell
+		// or exec request is made, the exec command is passed on to the game
+		// manager. The terminal's size from the pty-req and window-change
+		// requests is given to the player's session
 		go func(in <-chan *ssh.Request) {
+			var size game.TerminalSize
+			var session *game.Session
 			for req := range in {
-
-				// FIXME: is it possible to the the data from an exec request
-				// ssh test@localhost ls - can i pull out the 'ls' and do something with the exec command?
 				logger.Print(fmt.Sprintf("req: %v payload: %v", req.Type, string(req.Payload)))
 				switch req.Type {
 				case "pty-req":
+					ptySize, err := game.ParsePtyRequest(req.Payload)
+					if err != nil {
+						logger.Debug(fmt.Sprintf("error reading pty request: %v", err.Error()))
+						req.Reply(false, nil)
+						continue
+					}
+					size = ptySize
 					req.Reply(true, nil)
 					continue
+				case "window-change":
+					windowSize, err := game.ParseWindowChange(req.Payload)
+					if err != nil {
+						logger.Debug(fmt.Sprintf("error reading window change: %v", err.Error()))
+						continue
+					}
+					size = windowSize
+					if session != nil {
+						session.Resize(size)
+					}
+					continue
 				case "shell":
 					req.Reply(true, nil)
+					session = gm.HandleNewChannel(channel, sshConn.User(), sshConn.Permissions, nil, size)
+					continue
+				case "exec":
+					command, err := execCommand(req.Payload)
+					if err != nil {
+						logger.Debug(fmt.Sprintf("error reading exec request: %v", err.Error()))
+						req.Reply(false, nil)
+						continue
+					}
+					req.Reply(true, nil)
+					session = gm.HandleNewChannel(channel, sshConn.User(), sshConn.Permissions, strings.Fields(command), size)
 					continue
 				}
 				req.Reply(false, nil)
 			}
 		}(requests)
+	}
+}
+
+// execCommand returns the command from the payload of an exec request
+func execCommand(payload []byte) (string, error) {
+	execRequest := struct {
+		Command string
+	}{}
+	if err := ssh.Unmarshal(payload, &execRequest); err != nil {
+		return "", err
+	}
+	return execRequest.Command, nil
+}
+
+// analyzer scores the positions of finished games with the built-in engine
+type analyzer struct {
+	engine *engine.Engine
+}
 
-		//gm.HandleNewChannel(channel, sshConn.User())
-		gm.HandleNewChannel(channel, sshConn.User())
+func (a analyzer) Analyze(position *chess.Position) (game.Evaluation, error) {
+	result, err := a.engine.Search(position, 4, 300*time.Millisecond)
+	if err != nil {
+		return game.Evaluation{}, err
+	}
+	return game.Evaluation{Score: result.Score, PV: result.PV}, nil
+}
+
+// uciConfig returns the config for the uci engine at the path, UCI_MOVETIME and
+// UCI_TIMEOUT are in milliseconds
+func uciConfig(path string) uci.Config {
+	moveTime, err := strconv.Atoi(utils.GetEnv("UCI_MOVETIME", "1000"))
+	if err != nil {
+		panic("UCI_MOVETIME must be a number of milliseconds")
+	}
+	depth, err := strconv.Atoi(utils.GetEnv("UCI_DEPTH", "0"))
+	if err != nil {
+		panic("UCI_DEPTH must be a number")
+	}
+	timeout, err := strconv.Atoi(utils.GetEnv("UCI_TIMEOUT", "0"))
+	if err != nil {
+		panic("UCI_TIMEOUT must be a number of milliseconds")
+	}
+	poolSize, err := strconv.Atoi(utils.GetEnv("UCI_POOL_SIZE", "2"))
+	if err != nil {
+		panic("UCI_POOL_SIZE must be a number")
+	}
+	return uci.Config{
+		Path:     path,
+		MoveTime: time.Duration(moveTime) * time.Millisecond,
+		Depth:    depth,
+		Timeout:  time.Duration(timeout) * time.Millisecond,
+		PoolSize: poolSize,
 	}
 }
 
 func main() {
 	port := os.Getenv("PORT")
 
-	config := &ssh.ServerConfig{
-		NoClientAuth: true,
+	// players authenticate with their ssh key, the first key used with a name
+	// claims it. PLAYER_KEYS_FILE is the file the keys are kept in, they are
+	// only kept while the server runs without it
+	keys, err := auth.NewKeyStore(utils.GetEnv("PLAYER_KEYS_FILE", ""))
+	if err != nil {
+		panic(fmt.Sprintf("failed to load player keys: %v", err))
 	}
 
+	// players can also log in with the passwords in the players table of the
+	// postgres database at DATABASE_URL
+	var secrets auth.SecretStore
+	if url := utils.GetEnv("DATABASE_URL", ""); url != "" {
+		db, err := sql.Open("postgres", url)
+		if err != nil {
+			panic(fmt.Sprintf("failed to open the database: %v", err))
+		}
+		secrets = auth.NewPostgresSecrets(db)
+	}
+
+	// players without a key or a password play as guests unless REQUIRE_AUTH
+	// is true
+	requireAuth, err := strconv.ParseBool(utils.GetEnv("REQUIRE_AUTH", "false"))
+	if err != nil {
+		panic("REQUIRE_AUTH must be true or false")
+	}
+
+	config := auth.NewServerConfig(auth.Config{
+		Keys:        keys,
+		Secrets:     secrets,
+		RequireAuth: requireAuth,
+	})
+
 	privateBytes, err := ioutil.ReadFile("id_rsa")
 	if err != nil {
 		panic("Failed to load private key")
@@ -99,8 +227,58 @@ func main() {
 	// logger
 	logger := logruslogger.NewLogrusLogger(true)
 
+	// a player waiting for an opponent is offered a bot game after BOT_WAIT
+	// seconds, zero turns bot games off
+	botWait, err := strconv.Atoi(utils.GetEnv("BOT_WAIT", "30"))
+	if err != nil {
+		panic("BOT_WAIT must be a number of seconds")
+	}
+
+	// the seat of a player whose connection drops is held for RECONNECT_GRACE
+	// seconds, zero makes the player leave the game
+	reconnectGrace, err := strconv.Atoi(utils.GetEnv("RECONNECT_GRACE", "60"))
+	if err != nil {
+		panic("RECONNECT_GRACE must be a number of seconds")
+	}
+
+	// bots use the built-in engine unless UCI_ENGINE is the path to a uci
+	// engine such as stockfish
+	newBot := func(level int) game.Bot {
+		return engine.NewEngine(level)
+	}
+	if path := utils.GetEnv("UCI_ENGINE", ""); path != "" {
+		pool := uci.NewPool(uciConfig(path), logger)
+		newBot = func(level int) game.Bot {
+			return pool.Bot(level)
+		}
+	}
+
+	// puzzles are loaded from PUZZLE_FILE, a csv in the lichess puzzle format
+	var puzzles *puzzle.Store
+	if path := utils.GetEnv("PUZZLE_FILE", ""); path != "" {
+		loaded, err := puzzle.LoadFile(path)
+		if err != nil {
+			panic(fmt.Sprintf("failed to load puzzles: %v", err))
+		}
+		puzzles = puzzle.NewStore(loaded)
+	}
+
 	// create the GameManager
-	gm := game.NewGameManager(logger)
+	gm := game.NewGameManager(game.GameManagerConfig{
+		BotWait:        time.Duration(botWait) * time.Second,
+		ReconnectGrace: time.Duration(reconnectGrace) * time.Second,
+		NewBot:         newBot,
+		// hints come from a short search by the built-in engine
+		NewHintBot: func() game.Bot {
+			return engine.NewEngineWithLimits(4, 500*time.Millisecond)
+		},
+		NewAnalyzer: func() game.Analyzer {
+			return analyzer{engine: engine.NewEngine(engine.MaxLevel)}
+		},
+		Puzzles: puzzles,
+		Version: Version,
+		Build:   Build,
+	}, logger)
 
 	fmt.Printf("Listening on port %s for SSH...\n", port)
 
@@ -115,6 +293,6 @@ func main() {
 			panic("failed to accept incoming connection")
 		}
 
-		go handler(nConn, gm, config, logger)
+		go handler(nConn, gm, config, keys, logger)
 	}
 }
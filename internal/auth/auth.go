@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// GuestExtension is the permissions extension that is set for guests
+const GuestExtension = "guest"
+
+// Config is how players authenticate
+type Config struct {
+	// Keys are the names claimed by ssh keys
+	Keys *KeyStore
+	// Secrets are the hashes of the players' passwords, players can't use a
+	// password when it is nil
+	Secrets SecretStore
+	// RequireAuth turns guests off so every player needs a key or a password
+	RequireAuth bool
+}
+
+// NewServerConfig returns the server config that authenticates players by
+// their key or their password. Unless authentication is required players
+// without either play as guests under any name no key or password has
+func NewServerConfig(config Config) *ssh.ServerConfig {
+	server := &ssh.ServerConfig{
+		PublicKeyCallback: PublicKeyCallback(config.Keys, config.Secrets),
+	}
+	if config.Secrets != nil {
+		server.PasswordCallback = PasswordCallback(config.Secrets)
+	}
+	if !config.RequireAuth {
+		server.KeyboardInteractiveCallback = GuestCallback(config.Keys, config.Secrets)
+	}
+	return server
+}
+
+// GuestCallback returns the keyboard interactive callback that lets players
+// in as guests without asking them anything, ssh clients try it before
+// passwords so a name with a key or a password is rejected
+func GuestCallback(store *KeyStore, secrets SecretStore) func(ssh.ConnMetadata, ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+	return func(conn ssh.ConnMetadata, _ ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+		name := PlayerName(conn.User())
+		if name == "" {
+			return nil, errors.New("a player name is needed")
+		}
+		if store.Reserved(name) {
+			return nil, fmt.Errorf("the name %s is reserved for another key", name)
+		}
+		if err := checkUnregistered(secrets, name); err != nil {
+			return nil, err
+		}
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				PlayerExtension: name,
+				GuestExtension:  "true",
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	// PlayerExtension is the permissions extension that holds the name of the
+	// authenticated player
+	PlayerExtension = "player"
+	// FingerprintExtension is the permissions extension that holds the
+	// fingerprint of the player's key
+	FingerprintExtension = "fingerprint"
+)
+
+// KeyStore links the fingerprints of ssh keys to player names, the first key
+// used with a name claims it
+type KeyStore struct {
+	// path is the file the keys are saved to, the keys are only kept in
+	// memory when it is empty
+	path  string
+	mutex sync.Mutex
+	// names are the player names of each fingerprint
+	names map[string]string
+	// keys are the fingerprints of each player name
+	keys map[string]string
+}
+
+// NewKeyStore returns a key store that keeps the keys in the file at the path,
+// the keys already in the file are loaded
+func NewKeyStore(path string) (*KeyStore, error) {
+	s := &KeyStore{
+		path:  path,
+		names: map[string]string{},
+		keys:  map[string]string{},
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.names); err != nil {
+		return nil, fmt.Errorf("invalid key file %s: %v", path, err)
+	}
+	for fingerprint, name := range s.names {
+		s.keys[name] = fingerprint
+	}
+	return s, nil
+}
+
+// Identify returns the name of the player with the key, a known key gets its
+// own name back and a new key claims the name when no other key has
+func (s *KeyStore) Identify(name, fingerprint string) (string, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if known, ok := s.names[fingerprint]; ok {
+		return known, nil
+	}
+	if name == "" {
+		return "", errors.New("a player name is needed")
+	}
+	if _, ok := s.keys[name]; ok {
+		return "", fmt.Errorf("the name %s is reserved for another key", name)
+	}
+
+	s.names[fingerprint] = name
+	s.keys[name] = fingerprint
+	if err := s.save(); err != nil {
+		delete(s.names, fingerprint)
+		delete(s.keys, name)
+		return "", err
+	}
+	return name, nil
+}
+
+// Name returns the name the key has claimed
+func (s *KeyStore) Name(fingerprint string) (string, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	name, ok := s.names[fingerprint]
+	return name, ok
+}
+
+// Reserved returns true when a key has claimed the name
+func (s *KeyStore) Reserved(name string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	_, ok := s.keys[name]
+	return ok
+}
+
+func (s *KeyStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.names, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.path, data, 0600)
+}
+
+// PlayerName returns the player's name from the ssh user, the user can also
+// name a room after a #, for example alice#room
+func PlayerName(user string) string {
+	return strings.SplitN(user, "#", 2)[0]
+}
+
+// PublicKeyCallback returns the callback that authenticates players by their
+// key, the player's name and the key's fingerprint are passed to the session
+// in the permissions. A new key can't claim the name of a player with a
+// password, the secrets can be nil when there are none. ssh clients offer keys
+// before proving they have them so the callback only checks the name, the key
+// claims it in ClaimName once the handshake succeeds
+func PublicKeyCallback(store *KeyStore, secrets SecretStore) func(ssh.ConnMetadata, ssh.PublicKey) (*ssh.Permissions, error) {
+	return func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+		fingerprint := ssh.FingerprintSHA256(key)
+		name, known := store.Name(fingerprint)
+		if !known {
+			name = PlayerName(conn.User())
+			if name == "" {
+				return nil, errors.New("a player name is needed")
+			}
+			if store.Reserved(name) {
+				return nil, fmt.Errorf("the name %s is reserved for another key", name)
+			}
+			if err := checkUnregistered(secrets, name); err != nil {
+				return nil, err
+			}
+		}
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				PlayerExtension:      name,
+				FingerprintExtension: fingerprint,
+			},
+		}, nil
+	}
+}
+
+// ClaimName claims the player's name for the key the player authenticated
+// with, it is called once the handshake succeeds. The permissions of players
+// without a key are left as they are
+func ClaimName(store *KeyStore, permissions *ssh.Permissions) error {
+	if permissions == nil || permissions.Extensions[FingerprintExtension] == "" {
+		return nil
+	}
+	name, err := store.Identify(permissions.Extensions[PlayerExtension], permissions.Extensions[FingerprintExtension])
+	if err != nil {
+		return err
+	}
+	permissions.Extensions[PlayerExtension] = name
+	return nil
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+type testConn struct {
+	ssh.ConnMetadata
+	user string
+}
+
+func (c testConn) User() string {
+	return c.user
+}
+
+func newTestKey(t *testing.T) ssh.PublicKey {
+	public, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err, "should be nil")
+	key, err := ssh.NewPublicKey(public)
+	assert.Nil(t, err, "should be nil")
+	return key
+}
+
+func Test_Identify_Should_Return_The_Name_When_The_Key_Is_New_Or_Known(t *testing.T) {
+	store, err := NewKeyStore("")
+	assert.Nil(t, err, "should be nil")
+
+	name, err := store.Identify("alice", "alice-key")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", name, "should be equal")
+
+	name, err = store.Identify("bob", "alice-key")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", name, "should be equal")
+
+	name, err = store.Identify("", "alice-key")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", name, "should be equal")
+}
+
+func Test_Identify_Should_Return_Error_When_The_Name_Is_Reserved_Or_Empty(t *testing.T) {
+	store, _ := NewKeyStore("")
+	store.Identify("alice", "alice-key")
+
+	_, err := store.Identify("alice", "bob-key")
+	assert.EqualError(t, err, "the name alice is reserved for another key", "should be equal")
+
+	_, err = store.Identify("", "bob-key")
+	assert.EqualError(t, err, "a player name is needed", "should be equal")
+
+	name, err := store.Identify("bob", "bob-key")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "bob", name, "should be equal")
+}
+
+func Test_NewKeyStore_Should_Load_The_Keys_When_They_Were_Saved(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keys")
+	assert.Nil(t, err, "should be nil")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	store, err := NewKeyStore(path)
+	assert.Nil(t, err, "should be nil")
+	store.Identify("alice", "alice-key")
+
+	store, err = NewKeyStore(path)
+	assert.Nil(t, err, "should be nil")
+	name, err := store.Identify("bob", "alice-key")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", name, "should be equal")
+	_, err = store.Identify("alice", "bob-key")
+	assert.NotNil(t, err, "should not be nil")
+
+	ioutil.WriteFile(path, []byte("not json"), 0600)
+	_, err = NewKeyStore(path)
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_PlayerName_Should_Return_The_Name_When_Given_The_User(t *testing.T) {
+	tests := []struct {
+		user     string
+		expected string
+	}{
+		{"alice", "alice"},
+		{"alice#room", "alice"},
+		{"alice#room+5+3", "alice"},
+		{"#room", ""},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, PlayerName(test.user), "should be equal")
+	}
+}
+
+func Test_PublicKeyCallback_Should_Set_The_Permissions_When_The_Key_Is_Accepted(t *testing.T) {
+	store, _ := NewKeyStore("")
+	callback := PublicKeyCallback(store, testSecrets{"jack": ""})
+	alice, bob := newTestKey(t), newTestKey(t)
+
+	permissions, err := callback(testConn{user: "alice#room"}, alice)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", permissions.Extensions[PlayerExtension], "should be equal")
+	assert.Equal(t, ssh.FingerprintSHA256(alice), permissions.Extensions[FingerprintExtension], "should be equal")
+	assert.False(t, store.Reserved("alice"), "should not claim the name before the handshake succeeds")
+	assert.Nil(t, ClaimName(store, permissions), "should be nil")
+
+	permissions, err = callback(testConn{user: "alice"}, bob)
+	assert.EqualError(t, err, "the name alice is reserved for another key", "should be equal")
+	assert.Nil(t, permissions, "should be nil")
+
+	permissions, err = callback(testConn{user: "carol"}, alice)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "alice", permissions.Extensions[PlayerExtension], "should be equal")
+
+	_, err = callback(testConn{user: "jack"}, bob)
+	assert.EqualError(t, err, "the name jack needs a password", "should be equal")
+}
+
+func Test_ClaimName_Should_Return_Error_When_Another_Key_Claimed_The_Name_First(t *testing.T) {
+	store, _ := NewKeyStore("")
+	callback := PublicKeyCallback(store, nil)
+	alice, bob := newTestKey(t), newTestKey(t)
+
+	// both keys are checked before either handshake succeeds
+	first, _ := callback(testConn{user: "alice"}, alice)
+	second, _ := callback(testConn{user: "alice"}, bob)
+
+	assert.Nil(t, ClaimName(store, first), "should be nil")
+	assert.EqualError(t, ClaimName(store, second), "the name alice is reserved for another key", "should be equal")
+	assert.Nil(t, ClaimName(store, &ssh.Permissions{Extensions: map[string]string{PlayerExtension: "guest"}}), "should be nil")
+}
+
+// offeredKey offers the public key without having its private key, the
+// signature the client needs to authenticate can't be made
+type offeredKey struct {
+	key ssh.PublicKey
+}
+
+func (k offeredKey) PublicKey() ssh.PublicKey {
+	return k.key
+}
+
+func (k offeredKey) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return nil, errors.New("the private key is not known")
+}
+
+// handshake connects the user with the signer to a server with the key store
+// and claims the name like the server does, the server's error is returned
+func handshake(t *testing.T, store *KeyStore, user string, signer ssh.Signer) error {
+	_, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err, "should be nil")
+	hostKey, err := ssh.NewSignerFromKey(private)
+	assert.Nil(t, err, "should be nil")
+	config := NewServerConfig(Config{Keys: store, RequireAuth: true})
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.Nil(t, err, "should be nil")
+	defer listener.Close()
+	go func() {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		ssh.NewClientConn(conn, "", &ssh.ClientConfig{
+			User:            user,
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+	}()
+
+	serverConn, err := listener.Accept()
+	assert.Nil(t, err, "should be nil")
+	defer serverConn.Close()
+	conn, _, _, err := ssh.NewServerConn(serverConn, config)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return ClaimName(store, conn.Permissions)
+}
+
+func Test_Handshake_Should_Not_Claim_The_Name_When_The_Key_Is_Only_Offered(t *testing.T) {
+	store, _ := NewKeyStore("")
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err, "should be nil")
+	signer, _ := ssh.NewSignerFromKey(private)
+	key, _ := ssh.NewPublicKey(public)
+
+	assert.NotNil(t, handshake(t, store, "junk", offeredKey{key}), "should not be nil")
+	assert.False(t, store.Reserved("junk"), "should be false")
+	_, known := store.Name(ssh.FingerprintSHA256(key))
+	assert.False(t, known, "should be false")
+
+	assert.Nil(t, handshake(t, store, "victim", signer), "should be nil")
+	name, _ := store.Name(ssh.FingerprintSHA256(key))
+	assert.Equal(t, "victim", name, "should be equal")
+}
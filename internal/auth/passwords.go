@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/bcrypt"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrUnknownPlayer is returned when a player has no password
+var ErrUnknownPlayer = errors.New("unknown player")
+
+// SecretStore looks up the bcrypt hashes of the players' passwords
+type SecretStore interface {
+	// Secret returns the player's hash or ErrUnknownPlayer
+	Secret(name string) (string, error)
+}
+
+// PostgresSecrets looks up the hashes in the players table
+type PostgresSecrets struct {
+	db *sql.DB
+}
+
+// NewPostgresSecrets returns the secrets in the database's players table
+func NewPostgresSecrets(db *sql.DB) *PostgresSecrets {
+	return &PostgresSecrets{db: db}
+}
+
+// Secret returns the player's hash or ErrUnknownPlayer
+func (s *PostgresSecrets) Secret(name string) (string, error) {
+	secret := ""
+	err := s.db.QueryRow("SELECT secret FROM players WHERE username = $1", name).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", ErrUnknownPlayer
+	}
+	return secret, err
+}
+
+// checkUnregistered returns an error when the player has a password
+func checkUnregistered(secrets SecretStore, name string) error {
+	if secrets == nil {
+		return nil
+	}
+	_, err := secrets.Secret(name)
+	if err == ErrUnknownPlayer {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return fmt.Errorf("the name %s needs a password", name)
+}
+
+// PasswordCallback returns the callback that authenticates players by the
+// password of the name before the # in the ssh user
+func PasswordCallback(secrets SecretStore) func(ssh.ConnMetadata, []byte) (*ssh.Permissions, error) {
+	return func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+		name := PlayerName(conn.User())
+		secret, err := secrets.Secret(name)
+		if err != nil {
+			return nil, err
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(secret), password); err != nil {
+			return nil, fmt.Errorf("wrong password for %s", name)
+		}
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				PlayerExtension: name,
+			},
+		}, nil
+	}
+}
@@ -0,0 +1,101 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type testSecrets map[string]string
+
+func (s testSecrets) Secret(name string) (string, error) {
+	secret, ok := s[name]
+	if !ok {
+		return "", ErrUnknownPlayer
+	}
+	return secret, nil
+}
+
+func newTestSecrets(t *testing.T) testSecrets {
+	secret, err := bcrypt.GenerateFromPassword([]byte("password"), bcrypt.MinCost)
+	assert.Nil(t, err, "should be nil")
+	return testSecrets{"jack": string(secret)}
+}
+
+func Test_PasswordCallback_Should_Set_The_Player_When_The_Password_Is_Right(t *testing.T) {
+	callback := PasswordCallback(newTestSecrets(t))
+
+	for _, user := range []string{"jack", "jack#room", "jack#room+5+3"} {
+		permissions, err := callback(testConn{user: user}, []byte("password"))
+		assert.Nil(t, err, "should be nil")
+		assert.Equal(t, "jack", permissions.Extensions[PlayerExtension], "should be equal")
+	}
+}
+
+func Test_PasswordCallback_Should_Return_Error_When_The_Password_Is_Wrong_Or_Unknown(t *testing.T) {
+	callback := PasswordCallback(newTestSecrets(t))
+
+	tests := []struct {
+		user     string
+		password string
+		expected string
+	}{
+		{"jack", "wrong", "wrong password for jack"},
+		{"jack#room", "", "wrong password for jack"},
+		{"jim", "password", "unknown player"},
+	}
+
+	for _, test := range tests {
+		permissions, err := callback(testConn{user: test.user}, []byte(test.password))
+		assert.EqualError(t, err, test.expected, "should be equal")
+		assert.Nil(t, permissions, "should be nil")
+	}
+}
+
+func Test_GuestCallback_Should_Return_Error_When_The_Name_Has_A_Key_Or_A_Password(t *testing.T) {
+	store, _ := NewKeyStore("")
+	store.Identify("alice", "alice-key")
+	callback := GuestCallback(store, newTestSecrets(t))
+
+	tests := []struct {
+		user     string
+		expected string
+	}{
+		{"alice", "the name alice is reserved for another key"},
+		{"jack#room", "the name jack needs a password"},
+		{"#room", "a player name is needed"},
+	}
+
+	for _, test := range tests {
+		_, err := callback(testConn{user: test.user}, nil)
+		assert.EqualError(t, err, test.expected, "should be equal")
+	}
+
+	permissions, err := callback(testConn{user: "carol#room"}, nil)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "carol", permissions.Extensions[PlayerExtension], "should be equal")
+	assert.Equal(t, "true", permissions.Extensions[GuestExtension], "should be equal")
+}
+
+func Test_NewServerConfig_Should_Turn_Guests_Off_When_Auth_Is_Required(t *testing.T) {
+	store, _ := NewKeyStore("")
+
+	tests := []struct {
+		config    Config
+		passwords bool
+		guests    bool
+	}{
+		{Config{Keys: store}, false, true},
+		{Config{Keys: store, Secrets: testSecrets{}}, true, true},
+		{Config{Keys: store, Secrets: testSecrets{}, RequireAuth: true}, true, false},
+		{Config{Keys: store, RequireAuth: true}, false, false},
+	}
+
+	for _, test := range tests {
+		server := NewServerConfig(test.config)
+		assert.NotNil(t, server.PublicKeyCallback, "should not be nil")
+		assert.Equal(t, test.passwords, server.PasswordCallback != nil, "should be equal")
+		assert.Equal(t, test.guests, server.KeyboardInteractiveCallback != nil, "should be equal")
+	}
+}
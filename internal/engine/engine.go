@@ -0,0 +1,317 @@
+package engine
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	// MateScore is the score of a checkmate, mates found sooner score higher
+	MateScore = 100000
+
+	infinity = MateScore + 1
+
+	// MinLevel and MaxLevel are the weakest and strongest levels
+	MinLevel = 1
+	MaxLevel = 5
+
+	// the number of nodes searched between checks of the clock
+	nodesPerTimeCheck = 256
+
+	// maxPly stops the search following checks forever
+	maxPly = 128
+)
+
+var errTimeUp = errors.New("time is up")
+
+// level is how deep and how long the engine searches and how much the score of
+// each move at the root is changed at random to make mistakes
+type level struct {
+	depth    int
+	moveTime time.Duration
+	noise    int
+}
+
+var levels = map[int]level{
+	1: {depth: 1, moveTime: 500 * time.Millisecond, noise: 200},
+	2: {depth: 2, moveTime: time.Second, noise: 80},
+	3: {depth: 3, moveTime: 2 * time.Second, noise: 20},
+	4: {depth: 4, moveTime: 3 * time.Second, noise: 0},
+	5: {depth: 64, moveTime: 5 * time.Second, noise: 0},
+}
+
+// Result is the outcome of a search
+type Result struct {
+	Move *chess.Move
+	// Score is in centipawns for the side to move
+	Score int
+	Depth int
+	// PV is the line the engine expects to be played starting with Move
+	PV    []*chess.Move
+	Nodes int
+}
+
+// Engine searches for the best move with alpha-beta search and iterative
+// deepening, positions are scored by material and piece-square tables. An
+// engine can only search one position at a time
+type Engine struct {
+	level    level
+	random   *rand.Rand
+	deadline time.Time
+	nodes    int
+}
+
+// NewEngine returns an engine that plays at the level from MinLevel to
+// MaxLevel
+func NewEngine(strength int) *Engine {
+	if strength < MinLevel {
+		strength = MinLevel
+	}
+	if strength > MaxLevel {
+		strength = MaxLevel
+	}
+	return &Engine{
+		level:  levels[strength],
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// NewEngineWithLimits returns an engine that plays the best move it finds
+// searching to the depth or for the move time, whichever comes first
+func NewEngineWithLimits(depth int, moveTime time.Duration) *Engine {
+	return &Engine{
+		level:  level{depth: depth, moveTime: moveTime},
+		random: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// Move returns the move the engine plays in the position
+func (e *Engine) Move(position *chess.Position) (*chess.Move, error) {
+	result, err := e.Search(position, e.level.depth, e.level.moveTime)
+	if err != nil {
+		return nil, err
+	}
+	return result.Move, nil
+}
+
+// Search searches the position until the depth is reached or the move time is
+// used and returns the result of the deepest search that finished
+func (e *Engine) Search(position *chess.Position, depth int, moveTime time.Duration) (Result, error) {
+	// the search works on a copy of the position as the position caches its
+	// moves and may be used while the engine is thinking
+	fen, err := chess.FEN(position.String())
+	if err != nil {
+		return Result{}, err
+	}
+	root := chess.NewGame(fen).Position()
+	if len(root.ValidMoves()) == 0 {
+		return Result{}, errors.New("there are no moves in the position")
+	}
+
+	e.deadline = time.Now().Add(moveTime)
+	e.nodes = 0
+
+	result := Result{}
+	for d := 1; d <= depth; d++ {
+		score, pv, err := e.searchRoot(root, d, result.PV)
+		if err != nil {
+			break
+		}
+		result = Result{Move: pv[0], Score: score, Depth: d, PV: pv, Nodes: e.nodes}
+
+		// there is no point looking deeper once a mate has been found
+		if score > MateScore-100 || score < -MateScore+100 {
+			break
+		}
+	}
+
+	// the search always finishes depth one unless it has no time at all
+	if result.Move == nil {
+		result.Move = orderMoves(root, root.ValidMoves(), nil)[0]
+	}
+	return result, nil
+}
+
+func (e *Engine) searchRoot(position *chess.Position, depth int, previousPV []*chess.Move) (int, []*chess.Move, error) {
+	var firstMove *chess.Move
+	if len(previousPV) > 0 {
+		firstMove = previousPV[0]
+	}
+
+	// the noise only changes which move is chosen, the search bounds use the
+	// real scores so the noise doesn't cut off better moves
+	bestScore, bestChoice := -infinity, -infinity
+	var bestPV []*chess.Move
+	alpha := -infinity
+	for _, move := range orderMoves(position, position.ValidMoves(), firstMove) {
+		score, pv, err := e.alphaBeta(position.Update(move), move.HasTag(chess.Check), depth-1, 1, -infinity, -alpha)
+		if err != nil {
+			return 0, nil, err
+		}
+		score = -score
+		choice := score
+		if e.level.noise > 0 {
+			choice += e.random.Intn(2*e.level.noise+1) - e.level.noise
+		}
+
+		if choice > bestChoice {
+			bestScore, bestChoice = score, choice
+			bestPV = append([]*chess.Move{move}, pv...)
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return bestScore, bestPV, nil
+}
+
+// alphaBeta returns the score of the position for the side to move and the
+// best line from the position, inCheck is true when the side to move is in
+// check
+func (e *Engine) alphaBeta(position *chess.Position, inCheck bool, depth, ply, alpha, beta int) (int, []*chess.Move, error) {
+	if err := e.countNode(); err != nil {
+		return 0, nil, err
+	}
+
+	moves := position.ValidMoves()
+	if len(moves) == 0 {
+		if position.Status() == chess.Checkmate {
+			return -MateScore + ply, nil, nil
+		}
+		return 0, nil, nil
+	}
+	if isInsufficientMaterial(position) {
+		return 0, nil, nil
+	}
+
+	if depth <= 0 {
+		score, err := e.quiescence(position, inCheck, ply, alpha, beta)
+		return score, nil, err
+	}
+
+	var bestPV []*chess.Move
+	for _, move := range orderMoves(position, moves, nil) {
+		score, pv, err := e.alphaBeta(position.Update(move), move.HasTag(chess.Check), depth-1, ply+1, -beta, -alpha)
+		if err != nil {
+			return 0, nil, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil, nil
+		}
+		if score > alpha {
+			alpha = score
+			bestPV = append([]*chess.Move{move}, pv...)
+		}
+	}
+	return alpha, bestPV, nil
+}
+
+// quiescence only searches captures so the position is not scored in the
+// middle of an exchange. A side in check can't stand pat so all of its moves
+// out of check are searched
+func (e *Engine) quiescence(position *chess.Position, inCheck bool, ply, alpha, beta int) (int, error) {
+	if err := e.countNode(); err != nil {
+		return 0, err
+	}
+
+	moves := position.ValidMoves()
+	if inCheck && len(moves) == 0 {
+		return -MateScore + ply, nil
+	}
+	if ply >= maxPly {
+		return sideToMoveScore(position), nil
+	}
+	if !inCheck {
+		standPat := sideToMoveScore(position)
+		if standPat >= beta {
+			return beta, nil
+		}
+		if standPat > alpha {
+			alpha = standPat
+		}
+	}
+
+	for _, move := range orderMoves(position, moves, nil) {
+		if !inCheck && !move.HasTag(chess.Capture) && move.Promo() == chess.NoPieceType {
+			continue
+		}
+		score, err := e.quiescence(position.Update(move), move.HasTag(chess.Check), ply+1, -beta, -alpha)
+		if err != nil {
+			return 0, err
+		}
+		score = -score
+		if score >= beta {
+			return beta, nil
+		}
+		if score > alpha {
+			alpha = score
+		}
+	}
+	return alpha, nil
+}
+
+func (e *Engine) countNode() error {
+	e.nodes++
+	if e.nodes%nodesPerTimeCheck == 0 && time.Now().After(e.deadline) {
+		return errTimeUp
+	}
+	return nil
+}
+
+func sideToMoveScore(position *chess.Position) int {
+	if position.Turn() == chess.White {
+		return Evaluate(position)
+	}
+	return -Evaluate(position)
+}
+
+// isInsufficientMaterial returns true when only the kings and at most one minor
+// piece are left so neither side can checkmate
+func isInsufficientMaterial(position *chess.Position) bool {
+	pieces := 0
+	for _, piece := range position.Board().SquareMap() {
+		switch piece.Type() {
+		case chess.King:
+		case chess.Knight, chess.Bishop:
+			pieces++
+		default:
+			return false
+		}
+	}
+	return pieces <= 1
+}
+
+// orderMoves puts the first move first, then captures of the most valuable
+// pieces by the least valuable pieces and promotions so the search can cut off
+// the other moves sooner
+func orderMoves(position *chess.Position, moves []*chess.Move, first *chess.Move) []*chess.Move {
+	board := position.Board()
+	scores := map[*chess.Move]int{}
+	for _, move := range moves {
+		score := 0
+		if first != nil && move.S1() == first.S1() && move.S2() == first.S2() && move.Promo() == first.Promo() {
+			score += 100000
+		}
+		if move.HasTag(chess.Capture) {
+			victim := board.Piece(move.S2()).Type()
+			if move.HasTag(chess.EnPassant) {
+				victim = chess.Pawn
+			}
+			score += 10*pieceValues[victim] - pieceValues[board.Piece(move.S1()).Type()]/10 + 1000
+		}
+		if move.Promo() != chess.NoPieceType {
+			score += pieceValues[move.Promo()]
+		}
+		scores[move] = score
+	}
+
+	sort.SliceStable(moves, func(i, j int) bool {
+		return scores[moves[i]] > scores[moves[j]]
+	})
+	return moves
+}
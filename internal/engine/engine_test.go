@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func position(t *testing.T, fen string) *chess.Position {
+	startingPosition, err := chess.FEN(fen)
+	assert.Nil(t, err, "should be nil")
+	return chess.NewGame(startingPosition).Position()
+}
+
+func Test_Evaluate_Should_Return_Zero_When_Given_The_Starting_Position(t *testing.T) {
+	assert.Equal(t, 0, Evaluate(chess.StartingPosition()), "should be equal")
+}
+
+func Test_Evaluate_Should_Favor_The_Side_With_More_Material(t *testing.T) {
+	assert.True(t, Evaluate(position(t, "4k3/8/8/8/8/8/8/3QK3 w - - 0 1")) > 800, "white should be ahead")
+	assert.True(t, Evaluate(position(t, "3qk3/8/8/8/8/8/8/4K3 w - - 0 1")) < -800, "black should be ahead")
+}
+
+func Test_Search_Should_Find_The_Best_Move_When_Given_A_Position(t *testing.T) {
+	tables := []struct {
+		fen          string
+		depth        int
+		expectedMove string
+	}{
+		// back rank mate
+		{"6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1", 2, "a1a8"},
+		// take the hanging queen
+		{"4k3/8/8/3q4/8/8/3R4/4K3 w - - 0 1", 3, "d2d5"},
+		// black mates with the rook
+		{"r5k1/8/8/8/8/8/5PPP/6K1 b - - 0 1", 2, "a8a1"},
+	}
+
+	for _, tt := range tables {
+		result, err := NewEngine(MaxLevel).Search(position(t, tt.fen), tt.depth, 10*time.Second)
+		assert.Nil(t, err, "should be nil")
+		assert.Equal(t, tt.expectedMove, result.Move.String(), "should be equal for %s", tt.fen)
+	}
+}
+
+func Test_Search_Should_Return_A_Mate_Score_When_There_Is_A_Checkmate(t *testing.T) {
+	result, err := NewEngine(MaxLevel).Search(position(t, "6k1/5ppp/8/8/8/8/8/R5K1 w - - 0 1"), 4, 10*time.Second)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, MateScore-1, result.Score, "should be equal")
+	assert.Equal(t, 1, result.Depth, "should stop searching after the mate is found")
+}
+
+func Test_Quiescence_Should_Search_The_Moves_Out_Of_Check_When_In_Check(t *testing.T) {
+	e := NewEngine(MaxLevel)
+	e.deadline = time.Now().Add(time.Second)
+
+	// the knight forks the king and queen so white loses the queen
+	score, err := e.quiescence(position(t, "7k/8/8/8/8/Q7/2n5/4K3 w - - 0 1"), true, 1, -infinity, infinity)
+	assert.Nil(t, err, "should be nil")
+	assert.True(t, score < 0, "white should be behind")
+
+	score, err = e.quiescence(position(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1"), true, 1, -infinity, infinity)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, -MateScore+1, score, "should be equal")
+}
+
+func Test_Search_Should_Return_Error_When_There_Are_No_Moves(t *testing.T) {
+	_, err := NewEngine(MaxLevel).Search(position(t, "R5k1/5ppp/8/8/8/8/8/6K1 b - - 0 1"), 2, time.Second)
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_Move_Should_Return_A_Move_When_Given_The_Starting_Position(t *testing.T) {
+	for level := MinLevel; level <= 3; level++ {
+		move, err := NewEngine(level).Move(chess.StartingPosition())
+		assert.Nil(t, err, "should be nil")
+		assert.NotNil(t, move, "should not be nil")
+	}
+}
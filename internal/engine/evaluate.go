@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"github.com/notnil/chess"
+)
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+	chess.King:   0,
+}
+
+// piece-square tables from white's side of the board with the eighth rank first
+var pieceSquareTables = map[chess.PieceType][64]int{
+	chess.Pawn: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		50, 50, 50, 50, 50, 50, 50, 50,
+		10, 10, 20, 30, 30, 20, 10, 10,
+		5, 5, 10, 25, 25, 10, 5, 5,
+		0, 0, 0, 20, 20, 0, 0, 0,
+		5, -5, -10, 0, 0, -10, -5, 5,
+		5, 10, 10, -20, -20, 10, 10, 5,
+		0, 0, 0, 0, 0, 0, 0, 0,
+	},
+	chess.Knight: {
+		-50, -40, -30, -30, -30, -30, -40, -50,
+		-40, -20, 0, 0, 0, 0, -20, -40,
+		-30, 0, 10, 15, 15, 10, 0, -30,
+		-30, 5, 15, 20, 20, 15, 5, -30,
+		-30, 0, 15, 20, 20, 15, 0, -30,
+		-30, 5, 10, 15, 15, 10, 5, -30,
+		-40, -20, 0, 5, 5, 0, -20, -40,
+		-50, -40, -30, -30, -30, -30, -40, -50,
+	},
+	chess.Bishop: {
+		-20, -10, -10, -10, -10, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 10, 10, 5, 0, -10,
+		-10, 5, 5, 10, 10, 5, 5, -10,
+		-10, 0, 10, 10, 10, 10, 0, -10,
+		-10, 10, 10, 10, 10, 10, 10, -10,
+		-10, 5, 0, 0, 0, 0, 5, -10,
+		-20, -10, -10, -10, -10, -10, -10, -20,
+	},
+	chess.Rook: {
+		0, 0, 0, 0, 0, 0, 0, 0,
+		5, 10, 10, 10, 10, 10, 10, 5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		-5, 0, 0, 0, 0, 0, 0, -5,
+		0, 0, 0, 5, 5, 0, 0, 0,
+	},
+	chess.Queen: {
+		-20, -10, -10, -5, -5, -10, -10, -20,
+		-10, 0, 0, 0, 0, 0, 0, -10,
+		-10, 0, 5, 5, 5, 5, 0, -10,
+		-5, 0, 5, 5, 5, 5, 0, -5,
+		0, 0, 5, 5, 5, 5, 0, -5,
+		-10, 5, 5, 5, 5, 5, 0, -10,
+		-10, 0, 5, 0, 0, 0, 0, -10,
+		-20, -10, -10, -5, -5, -10, -10, -20,
+	},
+	chess.King: {
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-30, -40, -40, -50, -50, -40, -40, -30,
+		-20, -30, -30, -40, -40, -30, -30, -20,
+		-10, -20, -20, -20, -20, -20, -20, -10,
+		20, 20, 0, 0, 0, 0, 20, 20,
+		20, 30, 10, 0, 0, 10, 30, 20,
+	},
+}
+
+// the king moves to the center once the queens and most pieces are gone
+var kingEndgameTable = [64]int{
+	-50, -40, -30, -20, -20, -30, -40, -50,
+	-30, -20, -10, 0, 0, -10, -20, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 30, 40, 40, 30, -10, -30,
+	-30, -10, 20, 30, 30, 20, -10, -30,
+	-30, -30, 0, 0, 0, 0, -30, -30,
+	-50, -30, -30, -30, -30, -30, -30, -50,
+}
+
+// Evaluate returns the score of the position in centipawns for white, material
+// plus a bonus for each piece from its piece-square table
+func Evaluate(position *chess.Position) int {
+	squares := position.Board().SquareMap()
+	endgame := isEndgame(squares)
+
+	score := 0
+	for square, piece := range squares {
+		value := pieceValues[piece.Type()] + pieceSquareValue(piece, square, endgame)
+		if piece.Color() == chess.White {
+			score += value
+		} else {
+			score -= value
+		}
+	}
+	return score
+}
+
+func pieceSquareValue(piece chess.Piece, square chess.Square, endgame bool) int {
+	table := pieceSquareTables[piece.Type()]
+	if piece.Type() == chess.King && endgame {
+		table = kingEndgameTable
+	}
+
+	// the tables start at a8 for white so black's squares are mirrored
+	rank, file := int(square.Rank()), int(square.File())
+	if piece.Color() == chess.White {
+		return table[(7-rank)*8+file]
+	}
+	return table[rank*8+file]
+}
+
+// isEndgame returns true when there are no queens or each side with a queen
+// has at most one minor piece besides it
+func isEndgame(squares map[chess.Square]chess.Piece) bool {
+	queens := map[chess.Color]int{}
+	minorPieces := map[chess.Color]int{}
+	rooks := map[chess.Color]int{}
+	for _, piece := range squares {
+		switch piece.Type() {
+		case chess.Queen:
+			queens[piece.Color()]++
+		case chess.Knight, chess.Bishop:
+			minorPieces[piece.Color()]++
+		case chess.Rook:
+			rooks[piece.Color()]++
+		}
+	}
+
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if queens[color] > 0 && (rooks[color] > 0 || minorPieces[color] > 1) {
+			return false
+		}
+	}
+	return true
+}
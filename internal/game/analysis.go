@@ -0,0 +1,426 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	// scores are capped so a mate counts as a large advantage
+	analysisMaxScore = 1000
+
+	// how long players can look at the analysis before they are disconnected
+	analysisViewTime = 10 * time.Minute
+
+	// the most moves shown in a best line
+	bestLineLength = 4
+)
+
+// Evaluation is the score of a position and the best line from it
+type Evaluation struct {
+	// Score is in centipawns for the side to move
+	Score int
+	// PV is the best line from the position
+	PV []*chess.Move
+}
+
+// Analyzer scores positions for the analysis shown after the game
+type Analyzer interface {
+	Analyze(position *chess.Position) (Evaluation, error)
+}
+
+// judgement is how much a move lost
+type judgement int
+
+const (
+	goodMove judgement = iota
+	inaccuracy
+	mistake
+	blunder
+)
+
+// the chance of winning a move has to lose to be judged
+var judgementThresholds = []struct {
+	judgement  judgement
+	winPercent float64
+}{
+	{blunder, 30},
+	{mistake, 20},
+	{inaccuracy, 10},
+}
+
+func (j judgement) String() string {
+	switch j {
+	case inaccuracy:
+		return "inaccuracy"
+	case mistake:
+		return "mistake"
+	case blunder:
+		return "blunder"
+	}
+	return ""
+}
+
+// nag returns the numeric annotation glyph used in a PGN
+func (j judgement) nag() string {
+	switch j {
+	case inaccuracy:
+		return "$6"
+	case mistake:
+		return "$2"
+	case blunder:
+		return "$4"
+	}
+	return ""
+}
+
+// symbol returns the annotation written after a move
+func (j judgement) symbol() string {
+	switch j {
+	case inaccuracy:
+		return "?!"
+	case mistake:
+		return "?"
+	case blunder:
+		return "??"
+	}
+	return ""
+}
+
+// moveAnalysis is the judgement of one move and the line that was best instead
+type moveAnalysis struct {
+	color     chess.Color
+	san       string
+	judgement judgement
+	accuracy  float64
+	bestLine  []string
+}
+
+// analysis is the judgement of every move in a finished game
+type analysis struct {
+	moves []moveAnalysis
+}
+
+// analysisUpdate is sent while the positions are analysed, the analysis is set
+// once every position has been analysed
+type analysisUpdate struct {
+	done     int
+	total    int
+	analysis *analysis
+	err      error
+}
+
+// winPercent returns the chance of winning for a score in centipawns
+func winPercent(score int) float64 {
+	return 50 + 50*(2/(1+math.Exp(-0.00368208*float64(score)))-1)
+}
+
+// moveAccuracy returns the accuracy of a move from the chance of winning lost
+func moveAccuracy(winPercentLost float64) float64 {
+	accuracy := 103.1668*math.Exp(-0.04354*winPercentLost) - 3.1669
+	return math.Max(0, math.Min(100, accuracy))
+}
+
+func clampScore(score int) int {
+	if score > analysisMaxScore {
+		return analysisMaxScore
+	}
+	if score < -analysisMaxScore {
+		return -analysisMaxScore
+	}
+	return score
+}
+
+// judgeMove returns the judgement and accuracy of a move from the scores before
+// and after it, both for the player who moved
+func judgeMove(before, after int) (judgement, float64) {
+	lost := math.Max(0, winPercent(clampScore(before))-winPercent(clampScore(after)))
+	for _, threshold := range judgementThresholds {
+		if lost >= threshold.winPercent {
+			return threshold.judgement, moveAccuracy(lost)
+		}
+	}
+	return goodMove, moveAccuracy(lost)
+}
+
+// evaluate scores the position, positions without moves are scored without the
+// analyzer
+func evaluate(analyzer Analyzer, position *chess.Position) (Evaluation, error) {
+	switch position.Status() {
+	case chess.Checkmate:
+		return Evaluation{Score: -analysisMaxScore}, nil
+	case chess.Stalemate:
+		return Evaluation{}, nil
+	}
+	return analyzer.Analyze(position)
+}
+
+// sanLine returns the moves from the position in algebraic notation
+func sanLine(position *chess.Position, moves []*chess.Move) []string {
+	line := []string{}
+	for _, move := range moves {
+		if len(line) == bestLineLength {
+			break
+		}
+		line = append(line, chess.AlgebraicNotation{}.Encode(position, move))
+		position = position.Update(move)
+	}
+	return line
+}
+
+// analysisPositions returns a copy of the position before each ply and the
+// position the game ended in
+func (g *Game) analysisPositions() ([]*chess.Position, error) {
+	positions := []*chess.Position{}
+	for _, model := range g.models() {
+		for _, position := range model.Positions() {
+			fen, err := chess.FEN(position.String())
+			if err != nil {
+				return nil, err
+			}
+			positions = append(positions, chess.NewGame(fen).Position())
+		}
+	}
+	return positions, nil
+}
+
+// analyze judges each ply of the game, the positions are the position before
+// each ply followed by the position the game ended in
+func analyze(analyzer Analyzer, positions []*chess.Position, record []string, updates chan<- analysisUpdate) (*analysis, error) {
+	evaluations := []Evaluation{}
+	for i, position := range positions {
+		evaluation, err := evaluate(analyzer, position)
+		if err != nil {
+			return nil, err
+		}
+		evaluations = append(evaluations, evaluation)
+		// the progress is only shown when the game is ready for it
+		select {
+		case updates <- analysisUpdate{done: i + 1, total: len(positions)}:
+		default:
+		}
+	}
+
+	a := &analysis{}
+	for i, san := range record {
+		// the score after the move is for the opponent who is then to move
+		j, accuracy := judgeMove(evaluations[i].Score, -evaluations[i+1].Score)
+		move := moveAnalysis{
+			color:     positions[i].Turn(),
+			san:       san,
+			judgement: j,
+			accuracy:  accuracy,
+		}
+		if j != goodMove {
+			move.bestLine = sanLine(positions[i], evaluations[i].PV)
+		}
+		a.moves = append(a.moves, move)
+	}
+	return a, nil
+}
+
+// summary returns the color's average accuracy and the number of moves given
+// each judgement
+func (a *analysis) summary(color chess.Color) (float64, map[judgement]int) {
+	total, moves := 0.0, 0
+	judgements := map[judgement]int{}
+	for _, move := range a.moves {
+		if move.color != color {
+			continue
+		}
+		total += move.accuracy
+		moves++
+		judgements[move.judgement]++
+	}
+	if moves == 0 {
+		return 100, judgements
+	}
+	return total / float64(moves), judgements
+}
+
+// summaryString returns the color's accuracy and judgements, for example
+// accuracy 87%, 1 inaccuracy, 0 mistakes, 2 blunders
+func (a *analysis) summaryString(color chess.Color) string {
+	accuracy, judgements := a.summary(color)
+	words := []string{fmt.Sprintf("accuracy %.0f%%", accuracy)}
+	plurals := map[judgement]string{inaccuracy: "inaccuracies", mistake: "mistakes", blunder: "blunders"}
+	for _, j := range []judgement{inaccuracy, mistake, blunder} {
+		name := plurals[j]
+		if judgements[j] == 1 {
+			name = j.String()
+		}
+		words = append(words, fmt.Sprintf("%d %s", judgements[j], name))
+	}
+	return strings.Join(words, ", ")
+}
+
+// comment returns the PGN comment for the move, empty for good moves
+func (m moveAnalysis) comment() string {
+	if m.judgement == goodMove {
+		return ""
+	}
+	if len(m.bestLine) == 0 {
+		return m.judgement.String()
+	}
+	return fmt.Sprintf("%s. best was %s", m.judgement.String(), strings.Join(m.bestLine, " "))
+}
+
+// startAnalysis analyses the finished game in the background and shows the
+// analysis screen to the players
+func (g *Game) startAnalysis() {
+	g.showAnalysis = true
+	positions, err := g.analysisPositions()
+	if err != nil {
+		g.logger.Error(fmt.Sprintf("error analysing game: %v", err.Error()))
+		g.analysisErr = err
+		return
+	}
+
+	analyzer := g.newAnalyzer()
+	record := append([]string{}, g.record...)
+	go func() {
+		a, err := analyze(analyzer, positions, record, g.analysisUpdates)
+		if err != nil {
+			g.logger.Error(fmt.Sprintf("error analysing game: %v", err.Error()))
+		}
+		g.analysisUpdates <- analysisUpdate{analysis: a, err: err, done: len(positions), total: len(positions)}
+	}()
+}
+
+// updateAnalysis takes the progress of the analysis and closes the sessions of
+// players who have looked at the analysis for too long
+func (g *Game) updateAnalysis() {
+	for {
+		select {
+		case update := <-g.analysisUpdates:
+			g.analysisDone, g.analysisTotal = update.done, update.total
+			g.analysisErr = update.err
+			if update.analysis != nil {
+				g.analysis = update.analysis
+				g.Model.AddTagPair("Annotator", "ssh-chess")
+			}
+		default:
+			if g.showAnalysis && time.Since(g.endTime) > analysisViewTime {
+				g.showAnalysis = false
+				for _, session := range g.players() {
+					if session != nil {
+						g.RemoveSession(session, g.gameOverMessage())
+					}
+				}
+			}
+			return
+		}
+	}
+}
+
+// analysisLines returns the lines of the analysis screen
+func (g *Game) analysisLines() []string {
+	if g.analysisErr != nil {
+		return []string{"the game could not be analysed", "", strings.Split(g.gameOverMessage(), "\r\n")[0]}
+	}
+	if g.analysis == nil {
+		return []string{fmt.Sprintf("analysing the game %d/%d", g.analysisDone, g.analysisTotal)}
+	}
+
+	lines := []string{fmt.Sprintf("game over: %s by %s", outcomeString(g.outcome), g.method), ""}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		lines = append(lines, fmt.Sprintf("%s: %s", strings.ToLower(color.Name()), g.analysis.summaryString(color)))
+	}
+	lines = append(lines, "")
+
+	for i, move := range g.analysis.moves {
+		line := fmt.Sprintf("%s %s%s", g.moveNumberString(i), move.san, move.judgement.symbol())
+		if comment := move.comment(); comment != "" {
+			line += " " + comment
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// moveNumberString returns the move number of the ply, for example 12. for
+// white and 12... for black
+func (g *Game) moveNumberString(ply int) string {
+	fields := strings.Fields(g.models()[0].Positions()[0].String())
+	moveNumber := 0
+	fmt.Sscan(fields[5], &moveNumber)
+	if fields[1] == "b" {
+		ply++
+	}
+	if ply%2 == 0 {
+		return fmt.Sprintf("%d.", moveNumber+ply/2)
+	}
+	return fmt.Sprintf("%d...", moveNumber+ply/2)
+}
+
+// analysisString returns the part of the analysis screen the player has
+// scrolled to, the screen fills the terminal when its size is known
+func (g *Game) analysisString(p *Player, size TerminalSize) string {
+	lines := g.analysisLines()
+	width, height := g.analysisSize(size)
+
+	// the terminal can grow after the player scrolled
+	offset := p.AnalysisOffset
+	if maxOffset := g.maxAnalysisOffset(size); offset > maxOffset {
+		offset = maxOffset
+	}
+
+	end := offset + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	visible := []string{}
+	for _, line := range lines[offset:end] {
+		visible = append(visible, fitString(line, width))
+	}
+	for len(visible) < height {
+		visible = append(visible, "")
+	}
+	visible = append(visible, fitString("w/s or k/j scroll, q leaves", width))
+	return strings.Join(visible, "\r\n")
+}
+
+// analysisSize returns the width and the number of lines of analysis shown in
+// the terminal, the last line shows the keys
+func (g *Game) analysisSize(size TerminalSize) (int, int) {
+	if size.Width > 0 && size.Height > 0 {
+		return size.Width, size.Height - 1
+	}
+	return g.WorldWidth(), g.WorldHeight()
+}
+
+// maxAnalysisOffset returns how far the analysis can be scrolled down
+func (g *Game) maxAnalysisOffset(size TerminalSize) int {
+	_, height := g.analysisSize(size)
+	if maxOffset := len(g.analysisLines()) - height; maxOffset > 0 {
+		return maxOffset
+	}
+	return 0
+}
+
+// updateAnalysisScreen scrolls the analysis or leaves the game
+func (p *Player) updateAnalysisScreen(g *Game) {
+	size := TerminalSize{}
+	if p.s != nil {
+		size = p.s.TerminalSize()
+	}
+
+	switch p.currentKeyState {
+	case KeyUp:
+		if p.AnalysisOffset > 0 {
+			p.AnalysisOffset--
+		}
+	case KeyDown:
+		if p.AnalysisOffset < g.maxAnalysisOffset(size) {
+			p.AnalysisOffset++
+		}
+	case KeyQuit:
+		if p.s != nil {
+			g.RemoveSession(p.s, g.gameOverMessage())
+		}
+	}
+}
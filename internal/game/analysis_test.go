@@ -0,0 +1,109 @@
+package game
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+// testAnalyzer scores the position at each ply from the list and gives the
+// best line when there is one for the ply
+type testAnalyzer struct {
+	scores    []int
+	bestMoves map[int]string
+}
+
+func (a testAnalyzer) Analyze(position *chess.Position) (Evaluation, error) {
+	fields := strings.Fields(position.String())
+	moveNumber, _ := strconv.Atoi(fields[5])
+	ply := (moveNumber - 1) * 2
+	if fields[1] == "b" {
+		ply++
+	}
+
+	evaluation := Evaluation{Score: a.scores[ply]}
+	if bestMove, ok := a.bestMoves[ply]; ok {
+		move, _ := chess.UCINotation{}.Decode(position, bestMove)
+		evaluation.PV = []*chess.Move{move}
+	}
+	return evaluation, nil
+}
+
+// newTestAnalyzer returns the analyzer that finds a blunder in the second move
+// of the game
+func newTestAnalyzer() Analyzer {
+	return testAnalyzer{scores: []int{20, -20, 30, 600, -550}, bestMoves: map[int]string{2: "f1c4"}}
+}
+
+func Test_JudgeMove_Should_Judge_The_Move_By_The_Chance_Of_Winning_Lost(t *testing.T) {
+	tests := []struct {
+		before   int
+		after    int
+		expected judgement
+	}{
+		{0, 0, goodMove},
+		{0, -100, goodMove},
+		{0, -200, inaccuracy},
+		{0, -300, mistake},
+		{0, -600, blunder},
+		{5000, 1000, goodMove},
+		{-300, 0, goodMove},
+	}
+
+	for _, test := range tests {
+		j, _ := judgeMove(test.before, test.after)
+		assert.Equal(t, test.expected, j, "should be equal")
+	}
+}
+
+func Test_EndGame_Should_Annotate_The_PGN_When_The_Analysis_Finishes(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.newAnalyzer = newTestAnalyzer
+
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6")
+	g.Resign(white)
+	assert.True(t, g.showAnalysis, "should show the analysis")
+	updateUntil(g, func() bool { return g.analysis != nil })
+
+	assert.Equal(t, blunder, g.analysis.moves[2].judgement, "should be equal")
+	assert.Equal(t, goodMove, g.analysis.moves[3].judgement, "should be equal")
+	assert.True(t, strings.Contains(g.PGN(), "2. Nf3 $4 {blunder. best was Bc4} Nc6 0-1"), "should annotate the blunder")
+	assert.True(t, strings.Contains(g.PGN(), "[Annotator \"ssh-chess\"]"), "should add the annotator")
+	assert.Equal(t, "0 inaccuracies, 0 mistakes, 1 blunder", strings.SplitN(g.analysis.summaryString(chess.White), ", ", 2)[1], "should be equal")
+	assert.Equal(t, "accuracy 94%, 0 inaccuracies, 0 mistakes, 0 blunders", g.analysis.summaryString(chess.Black), "should be equal")
+
+	lines := g.analysisLines()
+	assert.Equal(t, "game over: black wins by resignation", lines[0], "should be equal")
+	assert.Equal(t, "2. Nf3?? blunder. best was Bc4", lines[7], "should be equal")
+	assert.Equal(t, "2... Nc6", lines[8], "should be equal")
+}
+
+func Test_AnalysisString_Should_Scroll_When_The_Player_Moves_Down(t *testing.T) {
+	g, white, _ := newStartedTestGame("alice", "bob")
+	g.analysis = &analysis{}
+	for i := 0; i < 40; i++ {
+		g.analysis.moves = append(g.analysis.moves, moveAnalysis{san: "Nf3"})
+	}
+	g.showAnalysis = true
+
+	assert.True(t, strings.HasPrefix(g.analysisString(white, TerminalSize{}), "game over"), "should start at the top")
+
+	pressKey(g, white, KeyUp)
+	assert.Equal(t, 0, white.AnalysisOffset, "should stop at the top")
+	pressKey(g, white, KeyDown)
+	assert.Equal(t, 1, white.AnalysisOffset, "should be equal")
+
+	for i := 0; i < 100; i++ {
+		pressKey(g, white, KeyDown)
+	}
+	assert.Equal(t, len(g.analysisLines())-g.WorldHeight(), white.AnalysisOffset, "should stop at the end")
+
+	// a taller terminal shows the last lines without scrolling the player
+	lines := strings.Split(g.analysisString(white, TerminalSize{Width: 80, Height: 40}), "\r\n")
+	assert.Equal(t, len(g.analysisLines())-g.WorldHeight(), white.AnalysisOffset, "should be equal")
+	assert.Equal(t, 40, len(lines), "should be equal")
+	assert.Equal(t, fitString(g.analysisLines()[len(g.analysisLines())-1], 80), lines[38], "should show the last line")
+}
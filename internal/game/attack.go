@@ -0,0 +1,84 @@
+package game
+
+import (
+	"github.com/notnil/chess"
+)
+
+var (
+	knightOffsets    = [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}}
+	kingOffsets      = [][2]int{{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1}}
+	rookDirections   = [][2]int{{1, 0}, {0, 1}, {-1, 0}, {0, -1}}
+	bishopDirections = [][2]int{{1, 1}, {-1, 1}, {-1, -1}, {1, -1}}
+)
+
+func pieceOn(board *chess.Board, file, rank int) (chess.Piece, bool) {
+	if file < 0 || file > 7 || rank < 0 || rank > 7 {
+		return chess.NoPiece, false
+	}
+	return board.Piece(chess.Square(rank*8 + file)), true
+}
+
+// squareAttacked returns true when a piece of the color attacks the square
+func squareAttacked(board *chess.Board, square chess.Square, by chess.Color) bool {
+	file, rank := int(square.File()), int(square.Rank())
+
+	// pawns attack diagonally forward so look one rank behind the square
+	pawnRank := rank - 1
+	if by == chess.Black {
+		pawnRank = rank + 1
+	}
+	for _, pawnFile := range []int{file - 1, file + 1} {
+		if piece, ok := pieceOn(board, pawnFile, pawnRank); ok && piece.Type() == chess.Pawn && piece.Color() == by {
+			return true
+		}
+	}
+
+	for _, offset := range knightOffsets {
+		if piece, ok := pieceOn(board, file+offset[0], rank+offset[1]); ok && piece.Type() == chess.Knight && piece.Color() == by {
+			return true
+		}
+	}
+
+	for _, offset := range kingOffsets {
+		if piece, ok := pieceOn(board, file+offset[0], rank+offset[1]); ok && piece.Type() == chess.King && piece.Color() == by {
+			return true
+		}
+	}
+
+	return sliderAttacks(board, file, rank, by, rookDirections, chess.Rook) ||
+		sliderAttacks(board, file, rank, by, bishopDirections, chess.Bishop)
+}
+
+func sliderAttacks(board *chess.Board, file, rank int, by chess.Color, directions [][2]int, pieceType chess.PieceType) bool {
+	for _, direction := range directions {
+		for distance := 1; ; distance++ {
+			piece, ok := pieceOn(board, file+direction[0]*distance, rank+direction[1]*distance)
+			if !ok {
+				break
+			}
+			if piece == chess.NoPiece {
+				continue
+			}
+			if piece.Color() == by && (piece.Type() == pieceType || piece.Type() == chess.Queen) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// kingSquare returns the square of the color's king
+func kingSquare(board *chess.Board, color chess.Color) chess.Square {
+	for square, piece := range board.SquareMap() {
+		if piece.Type() == chess.King && piece.Color() == color {
+			return square
+		}
+	}
+	return chess.NoSquare
+}
+
+func inCheck(board *chess.Board, color chess.Color) bool {
+	king := kingSquare(board, color)
+	return king != chess.NoSquare && squareAttacked(board, king, color.Other())
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/notnil/chess"
+)
+
+const (
+	// the level used when a player accepts a bot game without choosing one
+	defaultBotLevel = 3
+	minBotLevel     = 1
+	maxBotLevel     = 5
+)
+
+// Bot chooses the moves for a player without a session
+type Bot interface {
+	// Move returns the move to play in the position
+	Move(position *chess.Position) (*chess.Move, error)
+}
+
+// NewBotFunc returns a bot that plays at the level from 1 to 5
+type NewBotFunc func(level int) Bot
+
+// botMove is the move a bot chose for the position in the FEN
+type botMove struct {
+	fen  string
+	move *chess.Move
+	err  error
+}
+
+// NewBotPlayer returns a player without a session whose moves are chosen by
+// the bot
+func NewBotPlayer(bot Bot, name string, logger logger.Logger) *Player {
+	player := NewPlayer(nil, gameWidth, gameHeight, name, logger)
+	player.bot = bot
+	player.botMoves = make(chan botMove, 1)
+	return player
+}
+
+// IsBot returns true when the player's moves are chosen by a bot
+func (p *Player) IsBot() bool {
+	return p.bot != nil
+}
+
+// updateBot asks the bot for a move on its turn and plays the move once the bot
+// has chosen it, the bot thinks in the background so the game keeps running
+func (p *Player) updateBot(g *Game) {
+	// bots decline draw offers and takebacks
+	if p.Prompt != NoPrompt {
+		p.answerPrompt(g, false)
+	}
+
+	select {
+	case result := <-p.botMoves:
+		p.thinking = false
+		if !g.InProgress() || !p.IsActive || result.fen != g.Model.Position().String() {
+			return
+		}
+		if result.err != nil {
+			p.logger.Error(fmt.Sprintf("bot error: %v", result.err.Error()))
+			g.Resign(p)
+			return
+		}
+		if err := g.makeMove(result.move); err != nil {
+			p.logger.Error(fmt.Sprintf("bot move error: %v", err.Error()))
+			g.Resign(p)
+			return
+		}
+		g.moveMade()
+	default:
+	}
+
+	if g.InProgress() && p.IsActive && !p.thinking {
+		p.thinking = true
+		fen := g.Model.Position().String()
+		go func() {
+			// the bot gets its own copy of the position as the position caches
+			// its moves and is still used by the game
+			startingPosition, err := chess.FEN(fen)
+			if err != nil {
+				p.botMoves <- botMove{fen: fen, err: err}
+				return
+			}
+			move, err := p.bot.Move(chess.NewGame(startingPosition).Position())
+			p.botMoves <- botMove{fen: fen, move: move, err: err}
+		}()
+	}
+}
+
+// offerBot asks a player who has waited for an opponent for the bot wait time
+// if they want to play a bot instead
+func (g *Game) offerBot() {
+	if g.newBot == nil || g.botWait <= 0 || g.userCreatedGame || g.started || len(g.players()) != 1 {
+		return
+	}
+
+	for player := range g.players() {
+		if !player.botOffered && player.Prompt == NoPrompt && time.Since(player.CreatedAt) >= g.botWait {
+			player.botOffered = true
+			player.Prompt = BotPrompt
+		}
+	}
+}
+
+// addBot seats a bot at the level as the player's opponent
+func (g *Game) addBot(level int) {
+	if g.newBot == nil || g.started || len(g.players()) != 1 {
+		return
+	}
+
+	g.logger.Debug(fmt.Sprintf("adding a level %d bot to %s", level, g.Name))
+	g.bots = append(g.bots, NewBotPlayer(g.newBot(level), fmt.Sprintf("computer (level %d)", level), g.logger))
+}
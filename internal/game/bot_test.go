@@ -0,0 +1,113 @@
+package game
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+// testBot plays the first of its moves that is valid in the position
+type testBot struct {
+	moves []string
+}
+
+func (b *testBot) Move(position *chess.Position) (*chess.Move, error) {
+	for _, m := range b.moves {
+		for _, move := range position.ValidMoves() {
+			if move.String() == m {
+				return move, nil
+			}
+		}
+	}
+	return nil, errors.New("no move")
+}
+
+func newTestBotGame(bot Bot) *Game {
+	g := newTestGame()
+	g.botWait = time.Minute
+	g.newBot = func(level int) Bot {
+		return bot
+	}
+	return g
+}
+
+// updateUntil updates the game until the condition is true or a second passes
+func updateUntil(g *Game, condition func() bool) {
+	for start := time.Now(); !condition() && time.Since(start) < time.Second; {
+		g.Update(0)
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func Test_Update_Should_Offer_A_Bot_When_The_Player_Has_Waited(t *testing.T) {
+	g := newTestBotGame(&testBot{})
+	player := newTestPlayer(White)
+	addTestPlayers(g, player)
+
+	g.Update(0)
+	assert.Equal(t, NoPrompt, player.Prompt, "should be equal")
+
+	player.CreatedAt = time.Now().Add(-2 * time.Minute)
+	g.Update(0)
+	assert.Equal(t, BotPrompt, player.Prompt, "should be equal")
+
+	player.HandleBotLevel(2)
+	player.Update(g, 0)
+	assert.Equal(t, NoPrompt, player.Prompt, "should be equal")
+	assert.Equal(t, 2, g.PlayerCount(), "should be equal")
+	assert.Equal(t, 1, g.SessionCount(), "should be equal")
+	assert.Equal(t, "computer (level 2)", g.bots[0].Name, "should be equal")
+
+	// the offer is only made once
+	g.bots = nil
+	g.Update(0)
+	assert.Equal(t, NoPrompt, player.Prompt, "should be equal")
+}
+
+func Test_Update_Should_Not_Offer_A_Bot_When_The_Room_Was_Created_By_A_Player(t *testing.T) {
+	g := newTestBotGame(&testBot{})
+	g.userCreatedGame = true
+	player := newTestPlayer(White)
+	player.CreatedAt = time.Now().Add(-2 * time.Minute)
+	addTestPlayers(g, player)
+
+	g.Update(0)
+	assert.Equal(t, NoPrompt, player.Prompt, "should be equal")
+}
+
+func Test_Update_Should_Play_The_Bots_Move_When_It_Is_The_Bots_Turn(t *testing.T) {
+	g := newTestBotGame(&testBot{moves: []string{"e7e5"}})
+	player := newTestPlayer(White)
+	addTestPlayers(g, player)
+	g.addBot(defaultBotLevel)
+	g.started = true
+	bot := g.bots[0]
+	bot.SetColor(Black)
+	bot.IsActive = false
+
+	playMoves(g, player, bot, "e2e4")
+	assert.True(t, bot.IsActive, "bot should be active")
+
+	updateUntil(g, func() bool { return len(g.record) == 2 })
+	assert.Equal(t, []string{"e4", "e5"}, g.record, "should be equal")
+	assert.True(t, player.IsActive, "player should be active")
+	assert.False(t, bot.IsActive, "bot should not be active")
+}
+
+func Test_Update_Should_Decline_A_Draw_Offer_When_The_Opponent_Is_A_Bot(t *testing.T) {
+	g := newTestBotGame(&testBot{})
+	g.started = true
+	player := newTestPlayer(White)
+	addTestPlayers(g, player)
+	g.bots = append(g.bots, NewBotPlayer(&testBot{}, "computer", blanklogger.NewBlankLogger()))
+
+	pressKey(g, player, KeyOfferDraw)
+	g.Update(0)
+
+	assert.Equal(t, "draw offer declined", player.Message, "should be equal")
+	assert.True(t, g.InProgress(), "should still be in progress")
+}
@@ -0,0 +1,52 @@
+package game
+
+import (
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/notnil/chess"
+)
+
+// botGameTick is how often the loop of a game between bots is run
+const botGameTick = time.Millisecond
+
+// NewBotGame returns a game between two bots that is played without sessions,
+// the game is set up from the options like a named room
+func NewBotGame(name string, white, black *Player, options RoomOptions, logger logger.Logger) (*Game, error) {
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, name, options, logger)
+	if err != nil {
+		return nil, err
+	}
+
+	turn := colorFromModel(g.Model.Position().Turn())
+	white.SetColor(White)
+	white.IsActive = turn == White
+	black.SetColor(Black)
+	black.IsActive = turn == Black
+	g.bots = append(g.bots, white, black)
+	g.Model.AddTagPair("White", white.Name)
+	g.Model.AddTagPair("Black", black.Name)
+
+	g.started = true
+	g.startTime = time.Now()
+	if g.clock != nil {
+		g.clock.Start(turn)
+	}
+	return g, nil
+}
+
+// Play runs the game loop until the game ends
+func (g *Game) Play() {
+	lastUpdate := time.Now()
+	for !g.Ended() {
+		time.Sleep(botGameTick)
+		now := time.Now()
+		g.Update(float64(now.Sub(lastUpdate)) / float64(time.Millisecond))
+		lastUpdate = now
+	}
+}
+
+// Outcome returns the outcome of the game and how it ended
+func (g *Game) Outcome() (chess.Outcome, string) {
+	return g.outcome, g.method
+}
@@ -0,0 +1,268 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	botProtocolJSON = "json"
+	botProtocolText = "text"
+
+	// the most commands a bot can send before they are played
+	botCommandBuffer = 16
+)
+
+// a drop in uci notation, for example N@f3
+var uciDropRegexp = regexp.MustCompile(`^([PNBRQ])@([a-h][1-8])$`)
+
+// botEvent is a line sent to a bot connected over ssh
+type botEvent struct {
+	Type     string `json:"type"`
+	Color    string `json:"color,omitempty"`
+	Opponent string `json:"opponent,omitempty"`
+	Move     string `json:"move,omitempty"`
+	SAN      string `json:"san,omitempty"`
+	Result   string `json:"result,omitempty"`
+	WTime    *int64 `json:"wtime,omitempty"`
+	BTime    *int64 `json:"btime,omitempty"`
+	FEN      string `json:"fen,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Message  string `json:"message,omitempty"`
+	PGN      string `json:"pgn,omitempty"`
+}
+
+// text returns the event as words separated by spaces, the fen, method and
+// message can contain spaces so they come last
+func (e botEvent) text() string {
+	words := []string{e.Type}
+	for _, word := range []string{e.Color, e.Opponent, e.Move, e.SAN, e.Result} {
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	if e.WTime != nil && e.BTime != nil {
+		words = append(words, "wtime", strconv.FormatInt(*e.WTime, 10), "btime", strconv.FormatInt(*e.BTime, 10))
+	}
+	if e.FEN != "" {
+		words = append(words, "fen", e.FEN)
+	}
+	for _, text := range []string{e.Method, e.Message} {
+		if text != "" {
+			words = append(words, text)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// botProtocol sends a bot the events of its game instead of drawing the board,
+// the bot answers with moves in uci notation, one per line
+type botProtocol struct {
+	format  string
+	mutex   sync.Mutex
+	waiting bool
+	started bool
+	plies   int
+	ended   bool
+}
+
+// newBotProtocol returns the protocol for the arguments of the bot command,
+// events are sent as json unless the argument is text
+func newBotProtocol(args []string) (*botProtocol, error) {
+	format := botProtocolJSON
+	if len(args) > 0 {
+		format = args[0]
+	}
+	if len(args) > 1 || (format != botProtocolJSON && format != botProtocolText) {
+		return nil, fmt.Errorf("usage: bot [%s|%s]", botProtocolJSON, botProtocolText)
+	}
+	return &botProtocol{format: format}, nil
+}
+
+func (bp *botProtocol) encode(events []botEvent) string {
+	b := strings.Builder{}
+	for _, event := range events {
+		if bp.format == botProtocolText {
+			b.WriteString(event.text())
+		} else {
+			line, _ := json.Marshal(event)
+			b.Write(line)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// send writes the events to the bot
+func (bp *botProtocol) send(w io.Writer, events ...botEvent) {
+	bp.mutex.Lock()
+	defer bp.mutex.Unlock()
+	io.WriteString(w, bp.encode(events))
+}
+
+// render writes the events that happened since the last render
+func (bp *botProtocol) render(g *Game, s *Session) {
+	// the game loop holds the state mutex when it sends events so it is
+	// locked first
+	g.stateMutex.Lock()
+	bp.mutex.Lock()
+	defer bp.mutex.Unlock()
+	events := bp.events(g, s.Player)
+	g.stateMutex.Unlock()
+	io.WriteString(s, bp.encode(events))
+}
+
+// events returns the events the bot has not been sent yet
+func (bp *botProtocol) events(g *Game, p *Player) []botEvent {
+	events := []botEvent{}
+	if bp.ended {
+		return events
+	}
+
+	if !g.started {
+		if !bp.waiting {
+			bp.waiting = true
+			events = append(events, botEvent{Type: "waiting"})
+		}
+		return events
+	}
+
+	if !bp.started {
+		bp.started = true
+		event := botEvent{
+			Type:  "start",
+			Color: strings.ToLower(p.PlayerColor.modelColor().Name()),
+			FEN:   g.models()[0].Positions()[0].String(),
+		}
+		if opponent := g.opponent(p); opponent != nil {
+			event.Opponent = opponent.Name
+		}
+		g.setBotEventClock(&event)
+		events = append(events, event)
+		if token := g.tokenString(p); token != "" {
+			events = append(events, botEvent{Type: "token", Message: p.reconnectToken})
+		}
+	}
+
+	plies := len(g.uciRecord)
+	if plies < bp.plies {
+		event := botEvent{Type: "takeback", FEN: g.Model.Position().String()}
+		g.setBotEventClock(&event)
+		events = append(events, event)
+	}
+	for i := bp.plies; i < plies; i++ {
+		event := botEvent{Type: "move", Move: g.uciRecord[i], SAN: g.record[i]}
+		if i == plies-1 {
+			event.FEN = g.Model.Position().String()
+			g.setBotEventClock(&event)
+		}
+		events = append(events, event)
+	}
+	bp.plies = plies
+	return events
+}
+
+// end returns the events that are left and the end of the game
+func (bp *botProtocol) end(g *Game, p *Player) string {
+	bp.mutex.Lock()
+	defer bp.mutex.Unlock()
+
+	events := bp.events(g, p)
+	bp.ended = true
+	events = append(events, botEvent{
+		Type:   "end",
+		Result: g.outcome.String(),
+		Method: g.method,
+		PGN:    g.PGN(),
+	})
+	return bp.encode(events)
+}
+
+// setBotEventClock adds the time left for each player in milliseconds
+func (g *Game) setBotEventClock(event *botEvent) {
+	if g.clock == nil {
+		return
+	}
+	wtime := g.clock.Remaining(White).Milliseconds()
+	btime := g.clock.Remaining(Black).Milliseconds()
+	event.WTime, event.BTime = &wtime, &btime
+}
+
+// HandleCommand queues a line sent by a bot to be played on the next update
+func (p *Player) HandleCommand(command string) {
+	p.commands <- strings.TrimSpace(command)
+}
+
+// updateProtocol plays the moves sent by a bot connected over ssh, errors are
+// sent back to the bot
+func (p *Player) updateProtocol(g *Game) {
+	// bots connected over ssh decline draw offers, takebacks and bot games
+	if p.Prompt != NoPrompt {
+		p.answerPrompt(g, false)
+	}
+
+	for {
+		select {
+		case command := <-p.commands:
+			if err := p.playCommand(g, command); err != nil {
+				p.s.protocol.send(p.s, botEvent{Type: "error", Message: err.Error()})
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *Player) playCommand(g *Game, command string) error {
+	switch {
+	case command == "":
+		return nil
+	case !g.InProgress():
+		return errors.New("the game is not in progress")
+	case command == "resign":
+		g.Resign(p)
+		return nil
+	case !p.IsActive:
+		return errors.New("it is not your turn")
+	}
+
+	if err := g.playUCI(p.PlayerColor.modelColor(), command); err != nil {
+		return err
+	}
+	g.moveMade()
+	return nil
+}
+
+// playUCI plays the move given in uci notation. Drops are given as the piece
+// and square, for example N@f3, and chess960 castling as the king taking its
+// own rook
+func (g *Game) playUCI(color chess.Color, s string) error {
+	if match := uciDropRegexp.FindStringSubmatch(s); match != nil {
+		square := chess.Square(int(match[2][1]-'1')*8 + int(match[2][0]-'a'))
+		for _, pieceType := range reserveOrder {
+			if strings.ToUpper(pieceType.String()) == match[1] {
+				return g.dropPiece(color, pieceType, square)
+			}
+		}
+	}
+
+	move, err := chess.UCINotation{}.Decode(g.Model.Position(), s)
+	if err != nil {
+		return fmt.Errorf("invalid move: %s", s)
+	}
+
+	board := g.Model.Position().Board()
+	if g.chess960 && board.Piece(move.S1()).Type() == chess.King &&
+		board.Piece(move.S2()).Type() == chess.Rook && board.Piece(move.S2()).Color() == color {
+		return g.castle(color, move.S2())
+	}
+	return g.makeMove(move)
+}
@@ -0,0 +1,115 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_NewBotProtocol_Should_Return_An_Error_When_The_Format_Is_Unknown(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+		isErr    bool
+	}{
+		{[]string{}, botProtocolJSON, false},
+		{[]string{"json"}, botProtocolJSON, false},
+		{[]string{"text"}, botProtocolText, false},
+		{[]string{"xml"}, "", true},
+		{[]string{"text", "json"}, "", true},
+	}
+
+	for _, test := range tests {
+		bp, err := newBotProtocol(test.args)
+		if test.isErr {
+			assert.NotNil(t, err, "should not be nil")
+		} else {
+			assert.Nil(t, err, "should be nil")
+			assert.Equal(t, test.expected, bp.format, "should be equal")
+		}
+	}
+}
+
+func Test_Events_Should_Send_Each_Event_Once_When_The_Game_Goes_On(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	white.Name = "alice"
+	black.IsActive = false
+	bp := &botProtocol{format: botProtocolJSON}
+
+	assert.Equal(t, "{\"type\":\"waiting\"}\n", bp.encode(bp.events(g, black)), "should be equal")
+	assert.Equal(t, "", bp.encode(bp.events(g, black)), "should be equal")
+
+	addTestPlayers(g, white, black)
+	g.started = true
+	assert.Equal(t,
+		"{\"type\":\"start\",\"color\":\"black\",\"opponent\":\"alice\",\"fen\":\"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1\"}\n",
+		bp.encode(bp.events(g, black)), "should be equal")
+
+	playMoves(g, white, black, "e2e4")
+	assert.Equal(t,
+		"{\"type\":\"move\",\"move\":\"e2e4\",\"san\":\"e4\",\"fen\":\"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1\"}\n",
+		bp.encode(bp.events(g, black)), "should be equal")
+	assert.Equal(t, "", bp.encode(bp.events(g, black)), "should be equal")
+}
+
+func Test_Text_Should_Put_The_Words_With_Spaces_Last(t *testing.T) {
+	wtime, btime := int64(60000), int64(59000)
+
+	tests := []struct {
+		event    botEvent
+		expected string
+	}{
+		{botEvent{Type: "waiting"}, "waiting"},
+		{botEvent{Type: "start", Color: "white", Opponent: "alice", WTime: &wtime, BTime: &btime}, "start white alice wtime 60000 btime 59000"},
+		{botEvent{Type: "move", Move: "e7e5", SAN: "e5", FEN: "8/8/8/8/8/8/4K3/k6R b - - 0 40"}, "move e7e5 e5 fen 8/8/8/8/8/8/4K3/k6R b - - 0 40"},
+		{botEvent{Type: "end", Result: "1-0", Method: "time forfeit", PGN: "1. e4 1-0"}, "end 1-0 time forfeit"},
+		{botEvent{Type: "error", Message: "it is not your turn"}, "error it is not your turn"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.event.text(), "should be equal")
+	}
+}
+
+func Test_PlayCommand_Should_Play_The_Move_When_It_Is_The_Bots_Turn(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+
+	assert.NotNil(t, black.playCommand(g, "e7e5"), "should not be nil")
+	assert.NotNil(t, white.playCommand(g, "e2e5"), "should not be nil")
+	assert.NotNil(t, white.playCommand(g, "hello"), "should not be nil")
+	assert.Nil(t, white.playCommand(g, "e2e4"), "should be nil")
+	assert.Nil(t, black.playCommand(g, "e7e5"), "should be nil")
+
+	assert.Equal(t, []string{"e4", "e5"}, g.record, "should be equal")
+	assert.Equal(t, []string{"e2e4", "e7e5"}, g.uciRecord, "should be equal")
+	assert.True(t, white.IsActive, "white should be active")
+}
+
+func Test_PlayUCI_Should_Castle_When_The_King_Takes_Its_Own_Rook_In_Chess960(t *testing.T) {
+	g := newTestGame()
+	assert.Nil(t, g.setupChess960(chess960StandardIndex), "should be nil")
+
+	for _, move := range []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5", "e1h1"} {
+		assert.Nil(t, g.playUCI(g.Model.Position().Turn(), move), "should be nil")
+	}
+
+	assert.Equal(t, chess.WhiteKing, g.pieceAt(modelToPosition("g1")), "king should be on g1")
+	assert.Equal(t, chess.WhiteRook, g.pieceAt(modelToPosition("f1")), "rook should be on f1")
+	assert.Equal(t, "O-O", g.record[len(g.record)-1], "should be equal")
+	assert.Equal(t, "e1h1", g.uciRecord[len(g.uciRecord)-1], "should be equal")
+}
+
+func Test_PlayUCI_Should_Drop_The_Piece_When_Given_A_Drop(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+
+	for _, move := range []string{"e2e4", "d7d5", "e4d5", "g8f6"} {
+		assert.Nil(t, g.playUCI(g.Model.Position().Turn(), move), "should be nil")
+	}
+	assert.NotNil(t, g.playUCI(chess.White, "N@e4"), "should not be nil")
+	assert.Nil(t, g.playUCI(chess.White, "P@e6"), "should be nil")
+
+	assert.Equal(t, chess.WhitePawn, g.pieceAt(modelToPosition("e6")), "pawn should be on e6")
+	assert.Equal(t, "P@e6", g.uciRecord[len(g.uciRecord)-1], "should be equal")
+}
@@ -0,0 +1,307 @@
+package game
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const (
+	chess960Positions     = 960
+	chess960StandardIndex = 518
+)
+
+// the squares of the two knights among the five squares left after the
+// bishops and the queen are placed
+var chess960KnightPlacements = [10][2]int{
+	{0, 1}, {0, 2}, {0, 3}, {0, 4},
+	{1, 2}, {1, 3}, {1, 4},
+	{2, 3}, {2, 4},
+	{3, 4},
+}
+
+// chess960BackRank returns white's back rank for the starting position index
+// using Scharnagl's numbering where 518 is the standard setup
+func chess960BackRank(index int) (string, error) {
+	if index < 0 || index >= chess960Positions {
+		return "", fmt.Errorf("chess960 starting position must be between 0 and %d: %d", chess960Positions-1, index)
+	}
+
+	backRank := make([]rune, 8)
+	n := index
+
+	// light square bishop on b, d, f or h then dark square bishop on a, c, e or g
+	backRank[n%4*2+1] = 'B'
+	n = n / 4
+	backRank[n%4*2] = 'B'
+	n = n / 4
+
+	emptySquares := func() []int {
+		squares := []int{}
+		for i, r := range backRank {
+			if r == 0 {
+				squares = append(squares, i)
+			}
+		}
+		return squares
+	}
+
+	backRank[emptySquares()[n%6]] = 'Q'
+	n = n / 6
+
+	squares := emptySquares()
+	backRank[squares[chess960KnightPlacements[n][0]]] = 'N'
+	backRank[squares[chess960KnightPlacements[n][1]]] = 'N'
+
+	// the king is always between the rooks
+	squares = emptySquares()
+	backRank[squares[0]] = 'R'
+	backRank[squares[1]] = 'K'
+	backRank[squares[2]] = 'R'
+
+	return string(backRank), nil
+}
+
+// chess960FEN returns the starting position in X-FEN where the castling rights
+// refer to the outermost rooks
+func chess960FEN(index int) (string, error) {
+	backRank, err := chess960BackRank(index)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/pppppppp/8/8/8/8/PPPPPPPP/%s w KQkq - 0 1", strings.ToLower(backRank), backRank), nil
+}
+
+// chess960Rooks returns the squares of the rooks that can castle at the start
+func chess960Rooks(index int) []chess.Square {
+	backRank, _ := chess960BackRank(index)
+	rooks := []chess.Square{}
+	for file, r := range backRank {
+		if r == 'R' {
+			rooks = append(rooks, chess.Square(file), chess.Square(7*8+file))
+		}
+	}
+	return rooks
+}
+
+func castlingRookColor(rook chess.Square) chess.Color {
+	if rook.Rank() == chess.Rank1 {
+		return chess.White
+	}
+	return chess.Black
+}
+
+// castlingSquares returns where the king and rook end up after castling, the
+// king goes to the g or c file and the rook goes to the f or d file
+func castlingSquares(king, rook chess.Square) (chess.Square, chess.Square) {
+	rank := int(king.Rank()) * 8
+	if rook.File() > king.File() {
+		return chess.Square(rank + int(chess.FileG)), chess.Square(rank + int(chess.FileF))
+	}
+	return chess.Square(rank + int(chess.FileC)), chess.Square(rank + int(chess.FileD))
+}
+
+func (g *Game) canCastle(color chess.Color, rook chess.Square) bool {
+	if !g.chess960 || g.Model.Position().Turn() != color {
+		return false
+	}
+
+	hasRights := false
+	for _, castlingRook := range g.castlingRooks {
+		if castlingRook == rook {
+			hasRights = true
+		}
+	}
+	board := g.Model.Position().Board()
+	king := kingSquare(board, color)
+	if !hasRights || king == chess.NoSquare || king.Rank() != rook.Rank() ||
+		board.Piece(rook).Type() != chess.Rook || board.Piece(rook).Color() != color {
+		return false
+	}
+
+	// every square the king and rook cross must be empty apart from the king
+	// and rook themselves
+	kingTo, rookTo := castlingSquares(king, rook)
+	minFile, maxFile := int(king.File()), int(king.File())
+	for _, square := range []chess.Square{rook, kingTo, rookTo} {
+		if int(square.File()) < minFile {
+			minFile = int(square.File())
+		}
+		if int(square.File()) > maxFile {
+			maxFile = int(square.File())
+		}
+	}
+	for file := minFile; file <= maxFile; file++ {
+		square := chess.Square(int(king.Rank())*8 + file)
+		if square != king && square != rook && board.Piece(square) != chess.NoPiece {
+			return false
+		}
+	}
+
+	// the king can not castle out of, through or into check
+	squares := board.SquareMap()
+	delete(squares, king)
+	delete(squares, rook)
+	boardWithoutCastlingPieces := chess.NewBoard(squares)
+	step := 1
+	if kingTo.File() < king.File() {
+		step = -1
+	}
+	for file := int(king.File()); ; file += step {
+		square := chess.Square(int(king.Rank())*8 + file)
+		if squareAttacked(boardWithoutCastlingPieces, square, color.Other()) {
+			return false
+		}
+		if square == kingTo {
+			break
+		}
+	}
+	return true
+}
+
+// castlingTargets returns the positions the king on the position can be placed
+// on to castle. The king can be placed on the rook or on the square the king
+// castles to when that is not already a normal king move
+func (g *Game) castlingTargets(color chess.Color, from Position) map[Position]chess.Square {
+	targets := map[Position]chess.Square{}
+	if !g.chess960 {
+		return targets
+	}
+
+	king := from.positionToSquare()
+	if g.pieceAt(from).Type() != chess.King {
+		return targets
+	}
+
+	for _, rook := range g.castlingRooks {
+		if !g.canCastle(color, rook) {
+			continue
+		}
+		targets[squareToPosition(rook)] = rook
+
+		kingTo, _ := castlingSquares(king, rook)
+		if kingTo == king {
+			continue
+		}
+		isKingMove := false
+		for _, move := range g.Model.ValidMoves() {
+			if move.S1() == king && move.S2() == kingTo {
+				isKingMove = true
+			}
+		}
+		if !isKingMove {
+			targets[squareToPosition(kingTo)] = rook
+		}
+	}
+	return targets
+}
+
+// castle moves the king and rook. The model only knows the standard castling
+// rules so the game continues from the position after castling and the move is
+// kept in the game's record
+func (g *Game) castle(color chess.Color, rook chess.Square) error {
+	if !g.canCastle(color, rook) {
+		return fmt.Errorf("can not castle with the rook on %s", rook)
+	}
+
+	position := g.Model.Position()
+	king := kingSquare(position.Board(), color)
+	kingTo, rookTo := castlingSquares(king, rook)
+
+	squares := position.Board().SquareMap()
+	kingPiece, rookPiece := squares[king], squares[rook]
+	delete(squares, king)
+	delete(squares, rook)
+	squares[kingTo] = kingPiece
+	squares[rookTo] = rookPiece
+	board := chess.NewBoard(squares)
+
+	fields := strings.Fields(position.String())
+	halfMoveClock, _ := strconv.Atoi(fields[4])
+	moveNumber, _ := strconv.Atoi(fields[5])
+	if color == chess.Black {
+		moveNumber++
+	}
+	fen, err := chess.FEN(fmt.Sprintf("%s %s - - %d %d", board, color.Other(), halfMoveClock+1, moveNumber))
+	if err != nil {
+		return err
+	}
+	model := g.newModel(fen)
+
+	san := "O-O"
+	if rook.File() < king.File() {
+		san = "O-O-O"
+	}
+	if model.Position().Status() == chess.Checkmate {
+		san += "#"
+	} else if inCheck(board, color.Other()) {
+		san += "+"
+	}
+
+	g.castlingRooksHistory = append(g.castlingRooksHistory, g.castlingRooks)
+	castlingRooks := []chess.Square{}
+	for _, castlingRook := range g.castlingRooks {
+		if castlingRookColor(castlingRook) != color {
+			castlingRooks = append(castlingRooks, castlingRook)
+		}
+	}
+	g.castlingRooks = castlingRooks
+
+	g.history = append(g.history, g.Model)
+	g.Model = model
+	g.record = append(g.record, san)
+	g.uciRecord = append(g.uciRecord, king.String()+rook.String())
+	return nil
+}
+
+// updateCastlingRights removes the castling rights lost by the move, moving the
+// king loses both and moving or capturing a rook loses that side
+func (g *Game) updateCastlingRights(board *chess.Board, move *chess.Move) {
+	g.castlingRooksHistory = append(g.castlingRooksHistory, g.castlingRooks)
+
+	piece := board.Piece(move.S1())
+	castlingRooks := []chess.Square{}
+	for _, rook := range g.castlingRooks {
+		if rook == move.S1() || rook == move.S2() {
+			continue
+		}
+		if piece.Type() == chess.King && castlingRookColor(rook) == piece.Color() {
+			continue
+		}
+		castlingRooks = append(castlingRooks, rook)
+	}
+	g.castlingRooks = castlingRooks
+}
+
+// setupChess960 starts the game from the chess960 starting position, a
+// negative index picks a random starting position
+func (g *Game) setupChess960(index int) error {
+	if index < 0 {
+		index = rand.Intn(chess960Positions)
+	}
+
+	xfen, err := chess960FEN(index)
+	if err != nil {
+		return err
+	}
+
+	// the model only knows castling with the king on the e file so castling is
+	// handled by the game
+	fen, err := chess.FEN(strings.Replace(xfen, "KQkq", "-", 1))
+	if err != nil {
+		return err
+	}
+
+	g.chess960 = true
+	g.chess960Index = index
+	g.castlingRooks = chess960Rooks(index)
+	g.Model = g.newModel(fen)
+	g.Model.AddTagPair("Variant", "Chess960")
+	g.Model.AddTagPair("SetUp", "1")
+	g.Model.AddTagPair("FEN", xfen)
+	g.Model.AddTagPair("StartingPosition", strconv.Itoa(index))
+	return nil
+}
@@ -0,0 +1,83 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Chess960BackRank_Should_Return_The_Back_Rank_When_Given_An_Index(t *testing.T) {
+	tables := []struct {
+		index            int
+		expectedBackRank string
+	}{
+		{0, "BBQNNRKR"},
+		{518, "RNBQKBNR"},
+		{959, "RKRNNQBB"},
+	}
+
+	for _, tt := range tables {
+		backRankActual, err := chess960BackRank(tt.index)
+		assert.Nil(t, err, "should be nil")
+		assert.Equal(t, tt.expectedBackRank, backRankActual, "should be equal")
+	}
+}
+
+func Test_Chess960BackRank_Should_Return_Error_When_Given_An_Invalid_Index(t *testing.T) {
+	for _, index := range []int{-1, 960} {
+		_, err := chess960BackRank(index)
+		assert.NotNil(t, err, "should not be nil for %d", index)
+	}
+}
+
+func Test_ParseRoomOptions_Should_Return_Chess960_When_Given_A_Starting_Position(t *testing.T) {
+	options, err := ParseRoomOptions("room+chess960=100")
+	assert.Nil(t, err, "should be nil")
+	assert.True(t, options.Chess960, "should be true")
+	assert.Equal(t, 100, options.Chess960Index, "should be equal")
+
+	options, err = ParseRoomOptions("room+chess960")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, -1, options.Chess960Index, "should be equal")
+
+	_, err = ParseRoomOptions("room+chess960=960")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_Castle_Should_Move_The_King_And_Rook_When_The_King_Is_Placed_On_The_Rook(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Chess960: true, Chess960Index: chess960StandardIndex})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	addTestPlayers(g, white, black)
+	g.started = true
+	black.IsActive = false
+
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5")
+
+	white.BoardPosition = &Position{4, 7}
+	pressKey(g, white, KeyAction)
+	white.BoardPosition = &Position{7, 7}
+	pressKey(g, white, KeyAction)
+
+	assert.Equal(t, chess.WhiteKing, g.pieceAt(modelToPosition("g1")), "king should be on g1")
+	assert.Equal(t, chess.WhiteRook, g.pieceAt(modelToPosition("f1")), "rook should be on f1")
+	assert.Equal(t, chess.Black, g.Model.Position().Turn(), "should be black's turn")
+	assert.Equal(t, "O-O", g.record[len(g.record)-1], "should be equal")
+	assert.Contains(t, g.PGN(), "4. O-O", "should contain the castling move")
+	assert.False(t, g.canCastle(chess.White, chess.A1), "white should not be able to castle again")
+
+	assert.Nil(t, g.Takeback(white), "should be nil")
+	assert.Equal(t, chess.WhiteKing, g.pieceAt(modelToPosition("e1")), "king should be back on e1")
+	assert.Equal(t, chess.WhiteRook, g.pieceAt(modelToPosition("h1")), "rook should be back on h1")
+	assert.True(t, g.canCastle(chess.White, chess.H1), "white should be able to castle again")
+}
+
+func Test_Castle_Should_Not_Be_Allowed_When_The_King_Passes_Through_Check(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Chess960: true, Chess960Index: chess960StandardIndex})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "d7d6", "d2d3", "c8g4", "b1c3", "g4f3", "c1d2", "f3g2", "d1e2", "a7a6")
+
+	assert.True(t, g.canCastle(chess.White, chess.A1), "white should be able to castle queenside")
+	assert.False(t, g.canCastle(chess.White, chess.H1), "white should not be able to castle kingside")
+}
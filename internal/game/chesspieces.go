@@ -1,5 +1,9 @@
 package game
 
+import (
+	"github.com/notnil/chess"
+)
+
 type ChessPiecesColor int
 
 const (
@@ -26,3 +30,53 @@ const (
 func (c ChessPiecesColor) String() string {
 	return [...]string{whiteKing, blackKing}[c]
 }
+
+func (c ChessPiecesColor) modelColor() chess.Color {
+	return [...]chess.Color{chess.White, chess.Black}[c]
+}
+
+func (c ChessPiecesColor) pieceString(pieceType chess.PieceType) string {
+	switch pieceType {
+	case chess.King:
+		return [...]string{whiteKing, blackKing}[c]
+	case chess.Queen:
+		return [...]string{whiteQueen, blackQueen}[c]
+	case chess.Rook:
+		return [...]string{whiteRook, blackRook}[c]
+	case chess.Bishop:
+		return [...]string{whiteBishop, blackBishop}[c]
+	case chess.Knight:
+		return [...]string{whiteKnight, blackKnight}[c]
+	case chess.Pawn:
+		return [...]string{whitePawn, blackPawn}[c]
+	}
+	return " "
+}
+
+func colorFromModel(c chess.Color) ChessPiecesColor {
+	if c == chess.Black {
+		return Black
+	}
+	return White
+}
+
+// modelPieceString returns the glyph used to draw a piece from the model
+func modelPieceString(piece chess.Piece) string {
+	if piece == chess.NoPiece {
+		return " "
+	}
+	return colorFromModel(piece.Color()).pieceString(piece.Type())
+}
+
+// piece returns the model's piece of the type in the color
+func (c ChessPiecesColor) piece(pieceType chess.PieceType) chess.Piece {
+	for _, piece := range []chess.Piece{
+		chess.WhiteKing, chess.WhiteQueen, chess.WhiteRook, chess.WhiteBishop, chess.WhiteKnight, chess.WhitePawn,
+		chess.BlackKing, chess.BlackQueen, chess.BlackRook, chess.BlackBishop, chess.BlackKnight, chess.BlackPawn,
+	} {
+		if piece.Type() == pieceType && piece.Color() == c.modelColor() {
+			return piece
+		}
+	}
+	return chess.NoPiece
+}
@@ -0,0 +1,206 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type DelayMode int
+
+const (
+	// FischerIncrement adds the bonus to the clock after every move
+	FischerIncrement DelayMode = iota
+	// SimpleDelay waits for the bonus before the clock starts counting down
+	SimpleDelay
+	// BronsteinDelay adds back the time used on a move up to the bonus
+	BronsteinDelay
+)
+
+type TimeControl struct {
+	Base  time.Duration
+	Bonus time.Duration
+	Mode  DelayMode
+}
+
+// ParseTimeControl parses a time control given as minutes and a bonus in
+// seconds, for example 5+3 is a Fischer increment, 5+d3 is a simple delay and
+// 5+b3 is a Bronstein delay
+func ParseTimeControl(s string) (TimeControl, error) {
+	tc := TimeControl{}
+	parts := strings.Split(strings.ToLower(s), "+")
+	if len(parts) > 2 {
+		return tc, fmt.Errorf("invalid time control: %s", s)
+	}
+
+	minutes, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || minutes <= 0 {
+		return tc, fmt.Errorf("invalid number of minutes in time control: %s", s)
+	}
+	tc.Base = time.Duration(minutes * float64(time.Minute))
+
+	if len(parts) == 2 {
+		bonus := parts[1]
+		switch {
+		case strings.HasPrefix(bonus, "d"):
+			tc.Mode = SimpleDelay
+			bonus = bonus[1:]
+		case strings.HasPrefix(bonus, "b"):
+			tc.Mode = BronsteinDelay
+			bonus = bonus[1:]
+		}
+
+		seconds, err := strconv.Atoi(bonus)
+		if err != nil || seconds < 0 {
+			return tc, fmt.Errorf("invalid number of seconds in time control: %s", s)
+		}
+		tc.Bonus = time.Duration(seconds) * time.Second
+	}
+	return tc, nil
+}
+
+func (tc TimeControl) String() string {
+	minutes := strconv.FormatFloat(tc.Base.Minutes(), 'f', -1, 64)
+	seconds := int(tc.Bonus.Seconds())
+	switch tc.Mode {
+	case SimpleDelay:
+		return fmt.Sprintf("%s+d%d", minutes, seconds)
+	case BronsteinDelay:
+		return fmt.Sprintf("%s+b%d", minutes, seconds)
+	}
+	return fmt.Sprintf("%s+%d", minutes, seconds)
+}
+
+// PGNString returns the time control in the format used by the PGN TimeControl tag
+func (tc TimeControl) PGNString() string {
+	return fmt.Sprintf("%d+%d", int(tc.Base.Seconds()), int(tc.Bonus.Seconds()))
+}
+
+// Clock is ticked by the game loop and read when the game is rendered, its
+// mutex guards the remaining time
+type Clock struct {
+	mutex       sync.Mutex
+	timeControl TimeControl
+	remaining   [2]time.Duration
+	active      ChessPiecesColor
+	elapsed     time.Duration
+	running     bool
+}
+
+func NewClock(tc TimeControl) *Clock {
+	return &Clock{
+		timeControl: tc,
+		remaining:   [2]time.Duration{tc.Base, tc.Base},
+		active:      White,
+		elapsed:     0,
+		running:     false,
+	}
+}
+
+// Start starts counting down the time of the given color
+func (c *Clock) Start(active ChessPiecesColor) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.active = active
+	c.elapsed = 0
+	c.running = true
+}
+
+func (c *Clock) Stop() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.remaining[c.active] = c.timeLeft(c.active)
+	c.elapsed = 0
+	c.running = false
+}
+
+func (c *Clock) Tick(delta time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running {
+		c.elapsed += delta
+	}
+}
+
+// Press ends the turn of the active color and starts the time of the other color
+func (c *Clock) Press() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if !c.running {
+		return
+	}
+
+	remaining := c.timeLeft(c.active)
+	switch c.timeControl.Mode {
+	case FischerIncrement:
+		remaining += c.timeControl.Bonus
+	case BronsteinDelay:
+		if c.elapsed < c.timeControl.Bonus {
+			remaining += c.elapsed
+		} else {
+			remaining += c.timeControl.Bonus
+		}
+	}
+	c.remaining[c.active] = remaining
+
+	c.active = 1 - c.active
+	c.elapsed = 0
+}
+
+// SetRemaining sets the time the color has left, a player giving time odds
+// starts with less time
+func (c *Clock) SetRemaining(color ChessPiecesColor, remaining time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.remaining[color] = remaining
+}
+
+func (c *Clock) Remaining(color ChessPiecesColor) time.Duration {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.timeLeft(color)
+}
+
+// timeLeft returns the time the color has left, the caller holds the mutex
+func (c *Clock) timeLeft(color ChessPiecesColor) time.Duration {
+	if !c.running || color != c.active {
+		return c.remaining[color]
+	}
+
+	used := c.elapsed
+	if c.timeControl.Mode == SimpleDelay {
+		used -= c.timeControl.Bonus
+		if used < 0 {
+			used = 0
+		}
+	}
+
+	remaining := c.remaining[color] - used
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// Flagged returns the color whose time has run out
+func (c *Clock) Flagged() (ChessPiecesColor, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if c.running && c.timeLeft(c.active) <= 0 {
+		return c.active, true
+	}
+	return c.active, false
+}
+
+func (c *Clock) String(color ChessPiecesColor) string {
+	remaining := c.Remaining(color)
+	minutes := int(remaining / time.Minute)
+	seconds := int((remaining % time.Minute) / time.Second)
+	if remaining < 10*time.Second {
+		tenths := int((remaining % time.Second) / (100 * time.Millisecond))
+		return fmt.Sprintf("%d:%02d.%d", minutes, seconds, tenths)
+	}
+	return fmt.Sprintf("%d:%02d", minutes, seconds)
+}
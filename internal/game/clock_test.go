@@ -0,0 +1,107 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseTimeControl_Should_Return_Time_Control_When_Given_A_Valid_String(t *testing.T) {
+	tables := []struct {
+		timeControl         string
+		expectedTimeControl TimeControl
+	}{
+		{"5+0", TimeControl{5 * time.Minute, 0, FischerIncrement}},
+		{"5+3", TimeControl{5 * time.Minute, 3 * time.Second, FischerIncrement}},
+		{"15+d5", TimeControl{15 * time.Minute, 5 * time.Second, SimpleDelay}},
+		{"3+b2", TimeControl{3 * time.Minute, 2 * time.Second, BronsteinDelay}},
+		{"0.5+0", TimeControl{30 * time.Second, 0, FischerIncrement}},
+	}
+
+	for _, tt := range tables {
+		timeControlActual, err := ParseTimeControl(tt.timeControl)
+		assert.Nil(t, err, "should be nil")
+		assert.Equal(t, tt.expectedTimeControl, timeControlActual, "should be equal")
+		assert.Equal(t, tt.timeControl, timeControlActual.String(), "should be equal")
+	}
+}
+
+func Test_ParseTimeControl_Should_Return_Error_When_Given_An_Invalid_String(t *testing.T) {
+	for _, timeControl := range []string{"", "0", "five", "5+x3", "5+3+1", "5+-3"} {
+		_, err := ParseTimeControl(timeControl)
+		assert.NotNil(t, err, "should not be nil for %s", timeControl)
+	}
+}
+
+func Test_ParseRoomOptions_Should_Return_The_Time_Control_When_Given_A_Room_Name(t *testing.T) {
+	options, err := ParseRoomOptions("room+5+3")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "room", options.Name, "should be equal")
+	assert.Equal(t, &TimeControl{5 * time.Minute, 3 * time.Second, FischerIncrement}, options.TimeControl, "should be equal")
+
+	options, err = ParseRoomOptions("room")
+	assert.Nil(t, err, "should be nil")
+	assert.Nil(t, options.TimeControl, "should be nil")
+
+	_, err = ParseRoomOptions("room+fast")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_Clock_Should_Add_The_Bonus_When_A_Move_Is_Made(t *testing.T) {
+	tables := []struct {
+		timeControl       TimeControl
+		moveTime          time.Duration
+		expectedRemaining time.Duration
+	}{
+		{TimeControl{time.Minute, 3 * time.Second, FischerIncrement}, 10 * time.Second, 53 * time.Second},
+		{TimeControl{time.Minute, 3 * time.Second, SimpleDelay}, 2 * time.Second, time.Minute},
+		{TimeControl{time.Minute, 3 * time.Second, SimpleDelay}, 10 * time.Second, 53 * time.Second},
+		{TimeControl{time.Minute, 3 * time.Second, BronsteinDelay}, 2 * time.Second, time.Minute},
+		{TimeControl{time.Minute, 3 * time.Second, BronsteinDelay}, 10 * time.Second, 53 * time.Second},
+	}
+
+	for _, tt := range tables {
+		c := NewClock(tt.timeControl)
+		c.Start(White)
+		c.Tick(tt.moveTime)
+		c.Press()
+		assert.Equal(t, tt.expectedRemaining, c.Remaining(White), "should be equal for %s", tt.timeControl)
+		assert.Equal(t, time.Minute, c.Remaining(Black), "should be equal")
+	}
+}
+
+func Test_Clock_Should_Flag_When_Time_Runs_Out(t *testing.T) {
+	c := NewClock(TimeControl{time.Minute, 0, FischerIncrement})
+	c.Start(White)
+	c.Tick(30 * time.Second)
+	c.Press()
+	c.Tick(59 * time.Second)
+	_, flagged := c.Flagged()
+	assert.False(t, flagged, "should not be flagged")
+
+	c.Tick(time.Second)
+	color, flagged := c.Flagged()
+	assert.True(t, flagged, "should be flagged")
+	assert.Equal(t, Black, color, "should be equal")
+}
+
+func Test_CheckClock_Should_Draw_When_The_Player_With_Time_Has_Insufficient_Material(t *testing.T) {
+	g := newTestGame()
+	g.clock = NewClock(TimeControl{time.Minute, 0, FischerIncrement})
+	g.clock.Start(White)
+	g.clock.Tick(time.Minute)
+	g.checkClock()
+	assert.Equal(t, chess.BlackWon, g.outcome, "should be equal")
+
+	fen, _ := chess.FEN("4k3/8/8/8/8/8/4P3/3QK1N1 w - - 0 1")
+	g = newTestGame()
+	g.Model = chess.NewGame(fen)
+	g.clock = NewClock(TimeControl{time.Minute, 0, FischerIncrement})
+	g.clock.Start(White)
+	g.clock.Tick(time.Minute)
+	g.checkClock()
+	assert.Equal(t, chess.Draw, g.outcome, "should be equal")
+	assert.Contains(t, g.PGN(), "1/2-1/2", "should contain the result")
+}
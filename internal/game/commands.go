@@ -0,0 +1,415 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/notnil/chess"
+	"golang.org/x/crypto/ssh"
+)
+
+// jsonFlag is the argument that prints a command's output as JSON
+const jsonFlag = "--json"
+
+// the exit statuses sent when an exec command finishes
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+// errUsage is returned by a command given the wrong arguments
+var errUsage = errors.New("usage")
+
+// commandOutput is the output of an exec command, it is printed as text or
+// marshalled to JSON
+type commandOutput interface {
+	writeText(w io.Writer)
+}
+
+// execCommand is a command that prints its output and exits instead of joining
+// a game, for example ssh server -p 2022 games
+type execCommand struct {
+	usage       string
+	description string
+	run         func(args []string) (commandOutput, error)
+}
+
+// newExecCommands returns the game manager's exec commands
+func (gm *GameManager) newExecCommands() map[string]execCommand {
+	return map[string]execCommand{
+		"games": {
+			usage:       "games",
+			description: "list the games",
+			run:         gm.gamesCommand,
+		},
+		"players": {
+			usage:       "players",
+			description: "list the players",
+			run:         gm.playersCommand,
+		},
+		"stats": {
+			usage:       "stats",
+			description: "show the number of players and games",
+			run:         gm.statsCommand,
+		},
+		"pgn": {
+			usage:       "pgn <id>",
+			description: "print the PGN of a game",
+			run:         gm.pgnCommand,
+		},
+		"version": {
+			usage:       "version",
+			description: "show the server's version",
+			run:         gm.versionCommand,
+		},
+		"help": {
+			usage:       "help",
+			description: "list the commands",
+			run:         gm.helpCommand,
+		},
+	}
+}
+
+// runExecCommand runs the command and sends its exit status before closing the
+// channel
+func (gm *GameManager) runExecCommand(c ssh.Channel, command []string) {
+	status := gm.runCommand(c, c.Stderr(), command)
+	exitStatus := struct {
+		Status uint32
+	}{uint32(status)}
+	if _, err := c.SendRequest("exit-status", false, ssh.Marshal(&exitStatus)); err != nil {
+		gm.logger.Debug(fmt.Sprintf("error sending exit status: %v", err.Error()))
+	}
+	c.Close()
+}
+
+// runCommand writes the output of the command to stdout and its errors to
+// stderr and returns the exit status
+func (gm *GameManager) runCommand(stdout, stderr io.Writer, command []string) int {
+	name, args := command[0], []string{}
+	asJSON := false
+	for _, arg := range command[1:] {
+		if arg == jsonFlag {
+			asJSON = true
+		} else {
+			args = append(args, arg)
+		}
+	}
+
+	cmd := gm.commands[name]
+	output, err := cmd.run(args)
+	if err == errUsage {
+		fmt.Fprintf(stderr, "usage: %s\n", cmd.usage)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "%s\n", err.Error())
+		return exitFailure
+	}
+
+	if asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(output); err != nil {
+			fmt.Fprintf(stderr, "%s\n", err.Error())
+			return exitFailure
+		}
+		return exitSuccess
+	}
+	output.writeText(stdout)
+	return exitSuccess
+}
+
+// managedGame is a game and the kind of game it is
+type managedGame struct {
+	game *Game
+	kind string
+}
+
+// allGames returns a copy of every game the game manager has sorted by name
+func (gm *GameManager) allGames() []managedGame {
+	gm.gamesMutex.RLock()
+	games := []managedGame{}
+	for kind, list := range map[string]map[string]*Game{
+		"random": gm.Games,
+		"room":   gm.UserCreatedGames,
+		"puzzle": gm.PuzzleGames,
+		"drill":  gm.DrillGames,
+	} {
+		for _, g := range list {
+			games = append(games, managedGame{game: g, kind: kind})
+		}
+	}
+	gm.gamesMutex.RUnlock()
+
+	sort.Slice(games, func(i, j int) bool {
+		return games[i].game.Name < games[j].game.Name
+	})
+	return games
+}
+
+// status returns whether the game is waiting for players, being played or over
+func (g *Game) status() string {
+	switch {
+	case g.ended:
+		return "over"
+	case g.started:
+		return "playing"
+	}
+	return "waiting"
+}
+
+// sortedPlayers returns the game's players with white first once the game has
+// started and in the order they joined before
+func (g *Game) sortedPlayers() []*Player {
+	players := []*Player{}
+	for player := range g.players() {
+		players = append(players, player)
+	}
+	sort.Slice(players, func(i, j int) bool {
+		if g.started && players[i].PlayerColor != players[j].PlayerColor {
+			return players[i].PlayerColor == White
+		}
+		return players[i].CreatedAt.Before(players[j].CreatedAt)
+	})
+	return players
+}
+
+// gameSnapshot is what the commands show of a game
+type gameSnapshot struct {
+	status  string
+	players []playerInfo
+	plies   int
+	outcome chess.Outcome
+	method  string
+	pgn     string
+}
+
+// snapshot reads the game under the state mutex the game loop holds while it
+// updates the game so the commands never see a move half made
+func (g *Game) snapshot() gameSnapshot {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	snapshot := gameSnapshot{
+		status:  g.status(),
+		players: []playerInfo{},
+		plies:   len(g.record),
+		outcome: g.outcome,
+		method:  g.method,
+		pgn:     g.PGN(),
+	}
+	for _, player := range g.sortedPlayers() {
+		info := playerInfo{Name: player.Name, Type: "player", Game: g.Name, GameID: g.id}
+		if player.IsBot() || (player.s != nil && player.s.protocol != nil) {
+			info.Type = "bot"
+		} else if player.s != nil && player.s.Guest {
+			info.Type = "guest"
+		}
+		if g.started {
+			info.Color = strings.ToLower(player.PlayerColor.modelColor().Name())
+		}
+		snapshot.players = append(snapshot.players, info)
+	}
+	return snapshot
+}
+
+type gameInfo struct {
+	ID      string   `json:"id"`
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Status  string   `json:"status"`
+	Players []string `json:"players"`
+	Moves   int      `json:"moves"`
+	Result  string   `json:"result"`
+}
+
+type gameList []gameInfo
+
+func (l gameList) writeText(w io.Writer) {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "ID\tNAME\tTYPE\tSTATUS\tPLAYERS\tMOVES\tRESULT")
+	for _, info := range l {
+		fmt.Fprintf(t, "%s\t%s\t%s\t%s\t%s\t%d\t%s\n", info.ID, info.Name, info.Type, info.Status, strings.Join(info.Players, " vs "), info.Moves, info.Result)
+	}
+	t.Flush()
+}
+
+func (gm *GameManager) gamesCommand(args []string) (commandOutput, error) {
+	if len(args) > 0 {
+		return nil, errUsage
+	}
+	games := gameList{}
+	for _, managed := range gm.allGames() {
+		g := managed.game
+		snapshot := g.snapshot()
+		players := []string{}
+		for _, player := range snapshot.players {
+			players = append(players, player.Name)
+		}
+		games = append(games, gameInfo{
+			ID:      g.id,
+			Name:    g.Name,
+			Type:    managed.kind,
+			Status:  snapshot.status,
+			Players: players,
+			Moves:   (snapshot.plies + 1) / 2,
+			Result:  snapshot.outcome.String(),
+		})
+	}
+	return games, nil
+}
+
+type playerInfo struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Game   string `json:"game"`
+	GameID string `json:"game_id"`
+	Color  string `json:"color"`
+}
+
+type playerList []playerInfo
+
+func (l playerList) writeText(w io.Writer) {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(t, "NAME\tTYPE\tGAME\tCOLOR")
+	for _, info := range l {
+		fmt.Fprintf(t, "%s\t%s\t%s\t%s\n", info.Name, info.Type, info.Game, info.Color)
+	}
+	t.Flush()
+}
+
+func (gm *GameManager) playersCommand(args []string) (commandOutput, error) {
+	if len(args) > 0 {
+		return nil, errUsage
+	}
+	players := playerList{}
+	for _, managed := range gm.allGames() {
+		snapshot := managed.game.snapshot()
+		if snapshot.status == "over" {
+			continue
+		}
+		players = append(players, snapshot.players...)
+	}
+	return players, nil
+}
+
+type serverStats struct {
+	Players int     `json:"players"`
+	Games   int     `json:"games"`
+	Waiting int     `json:"waiting"`
+	Playing int     `json:"playing"`
+	Over    int     `json:"over"`
+	Uptime  float64 `json:"uptime"`
+}
+
+func (s serverStats) writeText(w io.Writer) {
+	fmt.Fprintf(w, "players: %d\n", s.Players)
+	fmt.Fprintf(w, "games: %d (%d waiting, %d playing, %d over)\n", s.Games, s.Waiting, s.Playing, s.Over)
+	fmt.Fprintf(w, "uptime: %s\n", (time.Duration(s.Uptime) * time.Second).String())
+}
+
+func (gm *GameManager) statsCommand(args []string) (commandOutput, error) {
+	if len(args) > 0 {
+		return nil, errUsage
+	}
+	stats := serverStats{
+		Players: gm.SessionCount(),
+		Games:   gm.GameCount(),
+		Uptime:  time.Since(gm.startTime).Truncate(time.Second).Seconds(),
+	}
+	for _, managed := range gm.allGames() {
+		switch managed.game.snapshot().status {
+		case "waiting":
+			stats.Waiting++
+		case "playing":
+			stats.Playing++
+		default:
+			stats.Over++
+		}
+	}
+	return stats, nil
+}
+
+type gamePGN struct {
+	ID  string `json:"id"`
+	PGN string `json:"pgn"`
+}
+
+func (p gamePGN) writeText(w io.Writer) {
+	fmt.Fprintln(w, p.PGN)
+}
+
+// pgnCommand prints the PGN of the game with the id or the name
+func (gm *GameManager) pgnCommand(args []string) (commandOutput, error) {
+	if len(args) != 1 {
+		return nil, errUsage
+	}
+	for _, managed := range gm.allGames() {
+		if g := managed.game; g.id == args[0] || g.Name == args[0] {
+			return gamePGN{ID: g.id, PGN: g.snapshot().pgn}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown game: %s", args[0])
+}
+
+type versionInfo struct {
+	Version string `json:"version"`
+	Build   string `json:"build"`
+}
+
+func (v versionInfo) writeText(w io.Writer) {
+	if v.Build == "" {
+		fmt.Fprintf(w, "ssh-chess %s\n", v.Version)
+		return
+	}
+	fmt.Fprintf(w, "ssh-chess %s (%s)\n", v.Version, v.Build)
+}
+
+func (gm *GameManager) versionCommand(args []string) (commandOutput, error) {
+	if len(args) > 0 {
+		return nil, errUsage
+	}
+	version := gm.config.Version
+	if version == "" {
+		version = "dev"
+	}
+	return versionInfo{Version: version, Build: gm.config.Build}, nil
+}
+
+type commandInfo struct {
+	Usage       string `json:"usage"`
+	Description string `json:"description"`
+}
+
+type commandList []commandInfo
+
+func (l commandList) writeText(w io.Writer) {
+	t := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, info := range l {
+		fmt.Fprintf(t, "%s\t%s\n", info.Usage, info.Description)
+	}
+	fmt.Fprintf(t, "\nadd %s to print the output as JSON\n", jsonFlag)
+	t.Flush()
+}
+
+func (gm *GameManager) helpCommand(args []string) (commandOutput, error) {
+	if len(args) > 0 {
+		return nil, errUsage
+	}
+	commands := commandList{}
+	for _, cmd := range gm.commands {
+		commands = append(commands, commandInfo{Usage: cmd.usage, Description: cmd.description})
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		return commands[i].Usage < commands[j].Usage
+	})
+	return commands, nil
+}
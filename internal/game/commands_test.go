@@ -0,0 +1,129 @@
+package game
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGameManager() *GameManager {
+	return NewGameManager(GameManagerConfig{Version: "v1.2.0", Build: "abc123"}, blanklogger.NewBlankLogger())
+}
+
+// runTestCommand runs the command and returns its exit status and output
+func runTestCommand(gm *GameManager, command ...string) (int, string, string) {
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	status := gm.runCommand(&stdout, &stderr, command)
+	return status, stdout.String(), stderr.String()
+}
+
+// addTestGame adds a started game between white and black with the moves
+// played
+func addTestGame(gm *GameManager, moves ...string) *Game {
+	g, white, black := newStartedTestGame("alice", "bob")
+	playMoves(g, white, black, moves...)
+	gm.Games[g.Name] = g
+	return g
+}
+
+func Test_RunCommand_Should_Return_The_Exit_Status_When_Given_The_Arguments(t *testing.T) {
+	gm := newTestGameManager()
+	addTestGame(gm)
+
+	tests := []struct {
+		command  []string
+		status   int
+		expected string
+	}{
+		{[]string{"version"}, exitSuccess, ""},
+		{[]string{"version", "now"}, exitUsage, "usage: version\n"},
+		{[]string{"pgn"}, exitUsage, "usage: pgn <id>\n"},
+		{[]string{"pgn", "missing"}, exitFailure, "unknown game: missing\n"},
+		{[]string{"pgn", "test"}, exitSuccess, ""},
+		{[]string{"games", "--json"}, exitSuccess, ""},
+	}
+
+	for _, test := range tests {
+		status, _, stderr := runTestCommand(gm, test.command...)
+		assert.Equal(t, test.status, status, "should be equal")
+		assert.Equal(t, test.expected, stderr, "should be equal")
+	}
+}
+
+func Test_RunCommand_Should_Print_Text_Or_JSON_When_Given_The_Version_Command(t *testing.T) {
+	gm := newTestGameManager()
+
+	_, stdout, _ := runTestCommand(gm, "version")
+	assert.Equal(t, "ssh-chess v1.2.0 (abc123)\n", stdout, "should be equal")
+
+	_, stdout, _ = runTestCommand(gm, "version", "--json")
+	version := versionInfo{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &version), "should be nil")
+	assert.Equal(t, versionInfo{Version: "v1.2.0", Build: "abc123"}, version, "should be equal")
+}
+
+func Test_RunCommand_Should_List_The_Games_When_Given_The_Games_Command(t *testing.T) {
+	gm := newTestGameManager()
+	g := addTestGame(gm, "e2e4", "e7e5", "g1f3")
+
+	_, stdout, _ := runTestCommand(gm, "games", "--json")
+	games := gameList{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &games), "should be nil")
+	assert.Equal(t, gameList{{
+		ID:      g.id,
+		Name:    "test",
+		Type:    "random",
+		Status:  "playing",
+		Players: []string{"alice", "bob"},
+		Moves:   2,
+		Result:  "*",
+	}}, games, "should be equal")
+
+	_, stdout, _ = runTestCommand(gm, "games")
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	assert.Equal(t, 2, len(lines), "should be equal")
+	assert.True(t, strings.HasPrefix(lines[0], "ID"), "should be true")
+	assert.True(t, strings.Contains(lines[1], "alice vs bob"), "should be true")
+	assert.False(t, strings.Contains(stdout, "\033"), "should be false")
+}
+
+func Test_RunCommand_Should_List_The_Players_When_Given_The_Players_Command(t *testing.T) {
+	gm := newTestGameManager()
+	g := addTestGame(gm)
+	waiting := newTestGame()
+	waiting.Name = "waiting"
+	carol := newTestPlayer(White)
+	carol.Name = "carol"
+	addTestPlayers(waiting, carol)
+	gm.UserCreatedGames[waiting.Name] = waiting
+
+	_, stdout, _ := runTestCommand(gm, "players", "--json")
+	players := playerList{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &players), "should be nil")
+	assert.Equal(t, playerList{
+		{Name: "alice", Type: "player", Game: "test", GameID: g.id, Color: "white"},
+		{Name: "bob", Type: "player", Game: "test", GameID: g.id, Color: "black"},
+		{Name: "carol", Type: "player", Game: "waiting", GameID: waiting.id},
+	}, players, "should be equal")
+
+	_, stdout, _ = runTestCommand(gm, "stats", "--json")
+	stats := serverStats{}
+	assert.Nil(t, json.Unmarshal([]byte(stdout), &stats), "should be nil")
+	assert.Equal(t, 2, stats.Games, "should be equal")
+	assert.Equal(t, 1, stats.Waiting, "should be equal")
+	assert.Equal(t, 1, stats.Playing, "should be equal")
+}
+
+func Test_RunCommand_Should_Print_The_PGN_When_Given_The_Game_ID(t *testing.T) {
+	gm := newTestGameManager()
+	g := addTestGame(gm, "e2e4", "e7e5")
+
+	status, stdout, _ := runTestCommand(gm, "pgn", g.id)
+	assert.Equal(t, exitSuccess, status, "should be equal")
+	assert.Equal(t, g.PGN()+"\n", stdout, "should be equal")
+	assert.True(t, strings.Contains(stdout, "1. e4 e5"), "should be true")
+}
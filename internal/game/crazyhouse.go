@@ -0,0 +1,244 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// the order pieces are shown in the reserve
+var reserveOrder = []chess.PieceType{chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn}
+
+// pieceDrop is a piece from the reserve placed on the board
+type pieceDrop struct {
+	color     chess.Color
+	pieceType chess.PieceType
+	square    chess.Square
+}
+
+// Crazyhouse puts captured pieces in the capturer's reserve, on their turn a
+// player can drop a piece from their reserve onto any empty square instead of
+// moving
+type Crazyhouse struct{}
+
+func (Crazyhouse) Name() string {
+	return "Crazyhouse"
+}
+
+func (Crazyhouse) Setup(g *Game) error {
+	if g.chess960 {
+		return errors.New("crazyhouse can not be played as chess960")
+	}
+	g.Model.AddTagPair("Variant", "Crazyhouse")
+	return nil
+}
+
+func (Crazyhouse) ValidMoves(g *Game, moves []*chess.Move) []*chess.Move {
+	return moves
+}
+
+// Outcome only ends the game by checkmate or stalemate when no drop changes the
+// position, captured pieces stay in the game so there are no draws by
+// insufficient material
+func (Crazyhouse) Outcome(g *Game) (chess.Outcome, string) {
+	turn := g.Model.Position().Turn()
+	switch g.Model.Position().Status() {
+	case chess.Checkmate:
+		if len(g.validDrops(turn)) == 0 {
+			return winner(turn.Other()), methodString(chess.Checkmate)
+		}
+	case chess.Stalemate:
+		if len(g.validDrops(turn)) == 0 {
+			return chess.Draw, methodString(chess.Stalemate)
+		}
+	}
+	return chess.NoOutcome, ""
+}
+
+func (Crazyhouse) Status(g *Game) string {
+	return ""
+}
+
+// TakenPieces returns the reserve, the pieces are shown in the color they are
+// dropped as
+func (Crazyhouse) TakenPieces(g *Game, color chess.Color) []string {
+	reserve := []string{}
+	for _, pieceType := range g.reserves()[color] {
+		reserve = append(reserve, colorFromModel(color).pieceString(pieceType))
+	}
+	return reserve
+}
+
+func (g *Game) crazyhouse() bool {
+	_, ok := g.variant.(Crazyhouse)
+	return ok
+}
+
+// reserves returns the pieces each color can drop. A captured piece that was
+// promoted goes into the reserve as a pawn
+func (g *Game) reserves() map[chess.Color][]chess.PieceType {
+	reserves := map[chess.Color][]chess.PieceType{}
+	promoted := map[chess.Square]bool{}
+
+	ply := 0
+	for i, model := range g.models() {
+		// every model after the first starts after a drop or a castle
+		if i > 0 {
+			if drop, ok := g.drops[ply]; ok {
+				reserves[drop.color] = removePieceType(reserves[drop.color], drop.pieceType)
+			}
+			ply++
+		}
+
+		positions := model.Positions()
+		for j, move := range model.Moves() {
+			board := positions[j].Board()
+			if piece := capturedPiece(board, move); piece != chess.NoPiece {
+				pieceType := piece.Type()
+				if promoted[move.S2()] {
+					pieceType = chess.Pawn
+				}
+				reserves[positions[j].Turn()] = append(reserves[positions[j].Turn()], pieceType)
+			}
+
+			wasPromoted := promoted[move.S1()]
+			delete(promoted, move.S1())
+			delete(promoted, move.S2())
+			if wasPromoted || move.Promo() != chess.NoPieceType {
+				promoted[move.S2()] = true
+			}
+			ply++
+		}
+	}
+
+	for color, reserve := range reserves {
+		reserves[color] = sortPieceTypes(reserve)
+	}
+	return reserves
+}
+
+// reservePieceTypes returns each type of piece in the color's reserve once
+func (g *Game) reservePieceTypes(color chess.Color) []chess.PieceType {
+	pieceTypes := []chess.PieceType{}
+	reserve := g.reserves()[color]
+	for _, pieceType := range reserveOrder {
+		for _, reservePieceType := range reserve {
+			if reservePieceType == pieceType {
+				pieceTypes = append(pieceTypes, pieceType)
+				break
+			}
+		}
+	}
+	return pieceTypes
+}
+
+func removePieceType(pieceTypes []chess.PieceType, pieceType chess.PieceType) []chess.PieceType {
+	for i, p := range pieceTypes {
+		if p == pieceType {
+			return append(append([]chess.PieceType{}, pieceTypes[:i]...), pieceTypes[i+1:]...)
+		}
+	}
+	return pieceTypes
+}
+
+func sortPieceTypes(pieceTypes []chess.PieceType) []chess.PieceType {
+	sorted := []chess.PieceType{}
+	for _, pieceType := range reserveOrder {
+		for _, p := range pieceTypes {
+			if p == pieceType {
+				sorted = append(sorted, p)
+			}
+		}
+	}
+	return sorted
+}
+
+// canDrop returns true when the color can drop the piece on the square. The
+// square has to be empty, pawns can not be dropped on the first or eighth rank
+// and the drop can not leave the color's king in check
+func (g *Game) canDrop(color chess.Color, pieceType chess.PieceType, square chess.Square) bool {
+	position := g.Model.Position()
+	if !g.crazyhouse() || position.Turn() != color || position.Board().Piece(square) != chess.NoPiece {
+		return false
+	}
+	if pieceType == chess.Pawn && (square.Rank() == chess.Rank1 || square.Rank() == chess.Rank8) {
+		return false
+	}
+
+	hasPiece := false
+	for _, p := range g.reserves()[color] {
+		if p == pieceType {
+			hasPiece = true
+		}
+	}
+	if !hasPiece {
+		return false
+	}
+
+	return !inCheck(g.boardAfterDrop(color, pieceType, square), color)
+}
+
+// validDrops returns every drop the color can make
+func (g *Game) validDrops(color chess.Color) []pieceDrop {
+	drops := []pieceDrop{}
+	for _, pieceType := range g.reservePieceTypes(color) {
+		for square := chess.A1; square <= chess.H8; square++ {
+			if g.canDrop(color, pieceType, square) {
+				drops = append(drops, pieceDrop{color: color, pieceType: pieceType, square: square})
+			}
+		}
+	}
+	return drops
+}
+
+func (g *Game) boardAfterDrop(color chess.Color, pieceType chess.PieceType, square chess.Square) *chess.Board {
+	squares := g.Model.Position().Board().SquareMap()
+	squares[square] = colorFromModel(color).piece(pieceType)
+	return chess.NewBoard(squares)
+}
+
+// dropPiece places the piece from the color's reserve on the square. The model
+// does not know about drops so the game continues from the position after the
+// drop and the drop is kept in the game's record
+func (g *Game) dropPiece(color chess.Color, pieceType chess.PieceType, square chess.Square) error {
+	if !g.canDrop(color, pieceType, square) {
+		return fmt.Errorf("can not drop %s on %s", pieceType, square)
+	}
+
+	position := g.Model.Position()
+	board := g.boardAfterDrop(color, pieceType, square)
+
+	fields := strings.Fields(position.String())
+	halfMoveClock, _ := strconv.Atoi(fields[4])
+	moveNumber, _ := strconv.Atoi(fields[5])
+	if color == chess.Black {
+		moveNumber++
+	}
+	fen, err := chess.FEN(fmt.Sprintf("%s %s %s - %d %d", board, color.Other(), fields[2], halfMoveClock+1, moveNumber))
+	if err != nil {
+		return err
+	}
+	model := g.newModel(fen)
+
+	g.castlingRooksHistory = append(g.castlingRooksHistory, g.castlingRooks)
+	g.history = append(g.history, g.Model)
+	g.Model = model
+	g.drops[len(g.record)] = pieceDrop{color: color, pieceType: pieceType, square: square}
+	g.record = append(g.record, g.dropString(pieceType, square, board, color))
+	g.uciRecord = append(g.uciRecord, fmt.Sprintf("%s@%s", strings.ToUpper(pieceType.String()), square))
+	return nil
+}
+
+// dropString returns the drop in drop notation, for example N@f3
+func (g *Game) dropString(pieceType chess.PieceType, square chess.Square, board *chess.Board, color chess.Color) string {
+	s := fmt.Sprintf("%s@%s", strings.ToUpper(pieceType.String()), square)
+	if outcome, _ := g.variant.Outcome(g); outcome != chess.NoOutcome && inCheck(board, color.Other()) {
+		s += "#"
+	} else if inCheck(board, color.Other()) {
+		s += "+"
+	}
+	return s
+}
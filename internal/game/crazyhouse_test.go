@@ -0,0 +1,87 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Crazyhouse_Should_Put_Captured_Pieces_In_The_Reserve(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	addTestPlayers(g, white, black)
+
+	playMoves(g, white, black, "e2e4", "d7d5", "e4d5", "d8d5")
+
+	assert.Equal(t, []chess.PieceType{chess.Pawn}, g.reserves()[chess.White], "should be equal")
+	assert.Equal(t, []chess.PieceType{chess.Pawn}, g.reserves()[chess.Black], "should be equal")
+	assert.Equal(t, []string{whitePawn}, white.TakenPiecesList, "should be equal")
+}
+
+func Test_Update_Should_Drop_A_Piece_From_The_Reserve_When_The_Square_Is_Empty(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+	g.started = true
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	black.IsActive = false
+	addTestPlayers(g, white, black)
+
+	playMoves(g, white, black, "e2e4", "d7d5", "e4d5", "g8f6")
+
+	pressKey(g, white, KeyReserve)
+	assert.Equal(t, SelectingReserve, white.PlayerState, "should be equal")
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, DroppingPiece, white.PlayerState, "should be equal")
+
+	// pawns can not be dropped on the eighth rank
+	white.BoardPosition = &Position{0, 0}
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, DroppingPiece, white.PlayerState, "should be equal")
+
+	white.BoardPosition = &Position{4, 4}
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, SelectingPiece, white.PlayerState, "should be equal")
+	assert.Equal(t, chess.WhitePawn, g.pieceAt(modelToPosition("e4")), "pawn should be dropped on e4")
+	assert.Equal(t, chess.Black, g.Model.Position().Turn(), "should be black's turn")
+	assert.Equal(t, 0, len(g.reserves()[chess.White]), "should be equal")
+	assert.Contains(t, g.PGN(), "3. P@e4", "should contain the drop")
+
+	assert.Nil(t, g.Takeback(white), "should be nil")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("e4")), "e4 should be empty")
+	assert.Equal(t, []chess.PieceType{chess.Pawn}, g.reserves()[chess.White], "should be equal")
+}
+
+func Test_Crazyhouse_Should_Only_Allow_Drops_That_Block_Check_When_In_Check(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e4", "d7d5", "e4d5", "e7e6", "d2d4", "f8b4")
+
+	assert.False(t, g.canDrop(chess.White, chess.Pawn, chess.H3), "should not be able to ignore the check")
+	assert.True(t, g.canDrop(chess.White, chess.Pawn, chess.C3), "should be able to block the check")
+}
+
+func Test_Crazyhouse_Should_Not_End_The_Game_When_A_Drop_Blocks_The_Checkmate(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	// the checkmate can be blocked by dropping the captured pawn on g3
+	playMoves(g, white, black, "f2f4", "e7e5", "f4e5", "d7d6", "g2g4", "d8h4")
+
+	assert.Equal(t, chess.Checkmate, g.Model.Position().Status(), "should be checkmate in the model")
+	assert.False(t, g.Ended(), "should not have ended")
+	assert.True(t, g.canDrop(chess.White, chess.Pawn, chess.G3), "should be able to block the check")
+	assert.Equal(t, "Qh4+", g.record[len(g.record)-1], "should be equal")
+}
+
+func Test_Crazyhouse_Should_Put_A_Pawn_In_The_Reserve_When_A_Promoted_Piece_Is_Captured(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "crazyhouse"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "b2b4", "a7a5", "b4a5", "b8c6", "a5a6", "a8b8", "a6b7", "c6d4")
+	white.SelectedPiecePosition, white.BoardPosition = &Position{1, 1}, &Position{2, 0}
+	white.movePiece(g, chess.Queen)
+	playMoves(g, white, black, "d8c8")
+
+	assert.Equal(t, []chess.PieceType{chess.Pawn}, g.reserves()[chess.Black], "should be equal")
+}
@@ -0,0 +1,218 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/notnil/chess"
+)
+
+const (
+	drillWon    = "completing the drill"
+	drillFailed = "failing the drill"
+)
+
+// drillGoal returns true once the player has reached the goal of the drill,
+// it is checked after every move with the position the drill started from
+type drillGoal func(start, position *chess.Position, player chess.Color) bool
+
+// drill is a position the player plays against the engine until the goal is
+// reached or the player runs out of moves
+type drill struct {
+	name        string
+	title       string
+	fen         string
+	description string
+	moveLimit   int
+	// draw drills are held until the move limit to complete them
+	draw bool
+	goal drillGoal
+}
+
+var drills = []drill{
+	{
+		name:        "kqk",
+		title:       "K+Q vs K",
+		fen:         "8/8/8/3k4/8/8/8/4K1Q1 w - - 0 1",
+		description: "checkmate",
+		moveLimit:   15,
+		goal:        mateGoal,
+	},
+	{
+		name:        "krk",
+		title:       "K+R vs K",
+		fen:         "8/8/8/4k3/8/8/8/R3K3 w - - 0 1",
+		description: "checkmate",
+		moveLimit:   25,
+		goal:        mateGoal,
+	},
+	{
+		name:        "lucena",
+		title:       "Lucena",
+		fen:         "1K1k4/1P6/8/8/8/8/r7/2R5 w - - 0 1",
+		description: "queen the pawn",
+		moveLimit:   15,
+		goal:        promoteGoal,
+	},
+	{
+		name:        "philidor",
+		title:       "Philidor",
+		fen:         "4k3/R7/1r6/3KP3/8/8/8/8 b - - 0 1",
+		description: "hold the draw",
+		moveLimit:   20,
+		draw:        true,
+		goal:        drawGoal,
+	},
+	{
+		name:        "kpk",
+		title:       "K+P vs K",
+		fen:         "4k3/8/4K3/4P3/8/8/8/8 w - - 0 1",
+		description: "queen the pawn",
+		moveLimit:   10,
+		goal:        promoteGoal,
+	},
+	{
+		name:        "kpk-defence",
+		title:       "K vs K+P",
+		fen:         "8/8/4k3/8/4P3/4K3/8/8 b - - 0 1",
+		description: "hold the draw",
+		moveLimit:   15,
+		draw:        true,
+		goal:        drawGoal,
+	},
+}
+
+// findDrill returns the drill with the name
+func findDrill(name string) (drill, error) {
+	names := []string{}
+	for _, d := range drills {
+		if d.name == name {
+			return d, nil
+		}
+		names = append(names, d.name)
+	}
+	return drill{}, fmt.Errorf("unknown drill: %s, choose one of %s", name, strings.Join(names, ", "))
+}
+
+// mateGoal is reached when the player checkmates the engine
+func mateGoal(start, position *chess.Position, player chess.Color) bool {
+	return position.Status() == chess.Checkmate && position.Turn() == player.Other()
+}
+
+// promoteGoal is reached when the player has promoted a pawn to a queen and the
+// engine has not taken it with its reply
+func promoteGoal(start, position *chess.Position, player chess.Color) bool {
+	queen := colorFromModel(player).piece(chess.Queen)
+	return position.Turn() == player && pieceCount(position, queen) > pieceCount(start, queen)
+}
+
+// drawGoal is reached when the player has taken the engine's pawns
+func drawGoal(start, position *chess.Position, player chess.Color) bool {
+	return pieceCount(position, colorFromModel(player.Other()).piece(chess.Pawn)) == 0
+}
+
+// pieceCount returns how many of the piece are on the board
+func pieceCount(position *chess.Position, piece chess.Piece) int {
+	count := 0
+	for _, p := range position.Board().SquareMap() {
+		if p == piece {
+			count++
+		}
+	}
+	return count
+}
+
+// drillState is the drill being played and the side the player has
+type drillState struct {
+	drill drill
+	color chess.Color
+	start *chess.Position
+}
+
+// NewDrillGame returns a game that starts from the drill's position with the
+// bot playing against the side to move
+func NewDrillGame(worldWidth, worldHeight int, name, drillName string, bot Bot, logger logger.Logger) (*Game, error) {
+	d, err := findDrill(drillName)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGame(worldWidth, worldHeight, name, logger)
+	if err := g.setupFEN(d.fen); err != nil {
+		return nil, err
+	}
+	g.Model.AddTagPair("Event", fmt.Sprintf("%s drill", d.title))
+	g.drill = &drillState{
+		drill: d,
+		color: g.Model.Position().Turn(),
+		start: g.Model.Position(),
+	}
+	g.bots = append(g.bots, NewBotPlayer(bot, "computer", logger))
+	return g, nil
+}
+
+// sortDrillPlayers puts the player before the bot so the player gets the side
+// to move
+func sortDrillPlayers(players []*Player) {
+	sort.SliceStable(players, func(i, j int) bool {
+		return !players[i].IsBot() && players[j].IsBot()
+	})
+}
+
+// drillMoves returns how many moves the player has made in the drill
+func (g *Game) drillMoves() int {
+	return (len(g.record) + 1) / 2
+}
+
+// checkDrill ends the game once the drill is completed or failed, it returns
+// true when the game has ended
+func (g *Game) checkDrill() bool {
+	d := g.drill.drill
+	player := g.drill.color
+	position := g.Model.Position()
+	opponentQueen := colorFromModel(player.Other()).piece(chess.Queen)
+
+	won := false
+	switch {
+	case d.goal(g.drill.start, position, player):
+		won = true
+	case g.Model.Method() == chess.Checkmate && position.Turn() == player:
+		// the engine checkmated the player
+	case g.Model.Outcome() == chess.Draw:
+		// stalemate or an automatic draw such as the engine taking the
+		// player's last piece
+		won = d.draw
+	case pieceCount(position, opponentQueen) > pieceCount(g.drill.start, opponentQueen):
+		// the engine promoted a pawn
+	case position.Turn() == player && g.drillMoves() >= d.moveLimit:
+		won = d.draw
+	default:
+		return false
+	}
+
+	outcome := winner(player.Other())
+	method := drillFailed
+	if won {
+		outcome = winner(player)
+		if d.draw {
+			outcome = chess.Draw
+		}
+		method = drillWon
+	}
+	g.logger.Debug(fmt.Sprintf("%s drill ended by %s", d.name, method))
+	g.endGame(outcome, method)
+	return true
+}
+
+// drillString returns the drill and the moves the player has left shown in the
+// header
+func (g *Game) drillString() string {
+	d := g.drill.drill
+	left := d.moveLimit - g.drillMoves()
+	if left < 0 {
+		left = 0
+	}
+	return fmt.Sprintf(" %s drill - %s in %d moves (%d left) ", d.title, d.description, d.moveLimit, left)
+}
@@ -0,0 +1,126 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestDrillGame(t *testing.T, name string) *Game {
+	g, err := NewDrillGame(gameWidth, gameHeight, "test", name, &testBot{}, blanklogger.NewBlankLogger())
+	assert.Nil(t, err, "should be nil")
+	g.started = true
+	return g
+}
+
+func Test_Drills_Should_Start_From_A_Valid_Position(t *testing.T) {
+	for _, d := range drills {
+		assert.Nil(t, validateFEN(d.fen), "should be nil")
+		g := newTestDrillGame(t, d.name)
+		assert.Equal(t, d.fen, g.Model.Position().String(), "should be equal")
+		assert.Equal(t, 1, g.PlayerCount(), "should seat the bot")
+	}
+}
+
+func Test_NewDrillGame_Should_Return_An_Error_When_The_Drill_Is_Unknown(t *testing.T) {
+	_, err := NewDrillGame(gameWidth, gameHeight, "test", "kbnk", &testBot{}, blanklogger.NewBlankLogger())
+
+	assert.Equal(t, "unknown drill: kbnk, choose one of kqk, krk, lucena, philidor, kpk, kpk-defence", err.Error(), "should be equal")
+}
+
+func Test_CheckDrill_Should_End_The_Drill_When_The_Goal_Is_Reached(t *testing.T) {
+	tests := []struct {
+		drill    string
+		fen      string
+		moves    []string
+		outcome  chess.Outcome
+		expected string
+	}{
+		{"kqk", "3k4/8/3K4/8/8/8/8/6Q1 w - - 0 1", []string{"g1g8"}, chess.WhiteWon, drillWon},
+		{"kqk", "3k4/8/3K4/8/8/8/8/6Q1 w - - 0 1", []string{"g1c5", "d8e8", "c5c7", "e8f8"}, chess.NoOutcome, ""},
+		{"kpk", "8/4P2k/8/8/8/8/8/4K3 w - - 0 1", []string{"e7e8q", "h7h6"}, chess.WhiteWon, drillWon},
+		{"kpk", "8/4P2k/8/8/8/8/8/4K3 w - - 0 1", []string{"e7e8q"}, chess.NoOutcome, ""},
+		{"philidor", "4k3/R7/1r6/3KP3/8/8/8/8 b - - 0 1", []string{"b6b5", "d5c6", "b5e5"}, chess.Draw, drillWon},
+		{"kpk-defence", "8/8/8/8/8/3k4/4P3/6K1 b - - 0 1", []string{"d3e2"}, chess.Draw, drillWon},
+	}
+
+	for _, test := range tests {
+		g := newTestDrillGame(t, test.drill)
+		assert.Nil(t, g.setupFEN(test.fen), "should be nil")
+		g.drill.start = g.Model.Position()
+
+		for _, move := range test.moves {
+			assert.Nil(t, g.playUCI(g.Model.Position().Turn(), move), "should be nil")
+			g.moveMade()
+		}
+
+		assert.Equal(t, test.outcome, g.outcome, "should be equal")
+		assert.Equal(t, test.expected, g.method, "should be equal")
+	}
+}
+
+func Test_CheckDrill_Should_Fail_The_Drill_When_The_Move_Limit_Is_Reached(t *testing.T) {
+	tests := []struct {
+		drill   string
+		moves   []string
+		outcome chess.Outcome
+	}{
+		{"kpk", []string{"e6d6", "e8d8"}, chess.BlackWon},
+		{"philidor", []string{"b6b1", "d5d6"}, chess.Draw},
+	}
+
+	for _, test := range tests {
+		g := newTestDrillGame(t, test.drill)
+		g.drill.drill.moveLimit = 1
+
+		assert.Nil(t, g.playUCI(g.Model.Position().Turn(), test.moves[0]), "should be nil")
+		g.moveMade()
+		assert.False(t, g.Ended(), "should not end before the reply")
+		assert.Nil(t, g.playUCI(g.Model.Position().Turn(), test.moves[1]), "should be nil")
+		g.moveMade()
+
+		assert.Equal(t, test.outcome, g.outcome, "should be equal")
+	}
+}
+
+func Test_CheckDrill_Should_Fail_The_Drill_When_The_Engine_Takes_The_Last_Piece(t *testing.T) {
+	tests := []struct {
+		drill   string
+		fen     string
+		moves   []string
+		outcome chess.Outcome
+		method  string
+	}{
+		{"kqk", "8/8/8/3k4/8/8/8/4K1Q1 w - - 0 1", []string{"g1d4", "d5d4"}, chess.BlackWon, drillFailed},
+		{"krk", "8/8/8/8/8/8/1k6/R3K3 w - - 0 1", []string{"e1e2", "b2a1"}, chess.BlackWon, drillFailed},
+		{"krk", "8/8/8/8/8/8/1k6/R3K3 w - - 0 1", []string{"a1a8"}, chess.NoOutcome, ""},
+	}
+
+	for _, test := range tests {
+		g := newTestDrillGame(t, test.drill)
+		assert.Nil(t, g.setupFEN(test.fen), "should be nil")
+		g.drill.start = g.Model.Position()
+
+		for _, move := range test.moves {
+			assert.Nil(t, g.playUCI(g.Model.Position().Turn(), move), "should be nil")
+			g.moveMade()
+		}
+
+		assert.Equal(t, test.outcome, g.outcome, "should be equal")
+		assert.Equal(t, test.method, g.method, "should be equal")
+	}
+}
+
+func Test_StartGame_Should_Give_The_Player_The_Side_To_Move_When_Playing_A_Drill(t *testing.T) {
+	g := newTestDrillGame(t, "philidor")
+	p := newTestPlayer(White)
+	p.IsActive = false
+	addTestPlayers(g, p)
+
+	g.startGame()
+
+	assert.Equal(t, Black, p.PlayerColor, "should be equal")
+	assert.True(t, p.IsActive, "should be active")
+}
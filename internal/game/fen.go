@@ -0,0 +1,63 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// the squares the king and rook have to be on for each castling right
+var castlingRightSquares = map[rune][2]chess.Square{
+	'K': {chess.E1, chess.H1},
+	'Q': {chess.E1, chess.A1},
+	'k': {chess.E8, chess.H8},
+	'q': {chess.E8, chess.A8},
+}
+
+// validateFEN returns a readable error when a game can not be started from the
+// FEN
+func validateFEN(fen string) error {
+	startingPosition, err := chess.FEN(fen)
+	if err != nil {
+		return fmt.Errorf("invalid fen: %s", strings.TrimPrefix(err.Error(), "chess: "))
+	}
+
+	fields := strings.Fields(fen)
+	position := chess.NewGame(startingPosition).Position()
+	board := position.Board()
+
+	kings := map[chess.Color]int{}
+	for square, piece := range board.SquareMap() {
+		if piece.Type() == chess.King {
+			kings[piece.Color()]++
+		}
+		if piece.Type() == chess.Pawn && (square.Rank() == chess.Rank1 || square.Rank() == chess.Rank8) {
+			return fmt.Errorf("invalid fen: there is a pawn on %s", square)
+		}
+	}
+	if kings[chess.White] != 1 || kings[chess.Black] != 1 {
+		return errors.New("invalid fen: each side must have one king")
+	}
+
+	if inCheck(board, position.Turn().Other()) {
+		return fmt.Errorf("invalid fen: %s is in check but it is not their turn", strings.ToLower(position.Turn().Other().Name()))
+	}
+
+	for _, right := range fields[2] {
+		squares, ok := castlingRightSquares[right]
+		if !ok {
+			continue
+		}
+		color := chess.White
+		if right == 'k' || right == 'q' {
+			color = chess.Black
+		}
+		king, rook := board.Piece(squares[0]), board.Piece(squares[1])
+		if king.Type() != chess.King || king.Color() != color || rook.Type() != chess.Rook || rook.Color() != color {
+			return fmt.Errorf("invalid fen: castling right %c needs the king on %s and the rook on %s", right, squares[0], squares[1])
+		}
+	}
+	return nil
+}
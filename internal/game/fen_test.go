@@ -0,0 +1,65 @@
+package game
+
+import (
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ValidateFEN_Should_Return_Nil_When_Given_A_Valid_FEN(t *testing.T) {
+	for _, fen := range []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1",
+		"8/8/8/8/8/4K3/8/4k2R b - - 0 40",
+	} {
+		assert.Nil(t, validateFEN(fen), "should be nil for %s", fen)
+	}
+}
+
+func Test_ValidateFEN_Should_Return_Error_When_Given_An_Invalid_FEN(t *testing.T) {
+	for _, fen := range []string{
+		"",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP w KQkq - 0 1",
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1",
+		"8/8/8/8/8/8/4K3/8 w - - 0 1",
+		"8/8/8/8/8/4K3/8/4k2R w - - 0 1",
+		"P7/8/8/8/8/8/4K3/4k3 w - - 0 1",
+		"8/8/8/8/8/4K3/8/4k2R b K - 0 1",
+	} {
+		assert.NotNil(t, validateFEN(fen), "should not be nil for %s", fen)
+	}
+}
+
+func Test_ParseRoomOptions_Should_Return_The_FEN_When_Given_A_FEN(t *testing.T) {
+	options, err := ParseRoomOptions("room+fen=8/8/8/8/8/4K3/8/4k2R_b_-_-_0_40+5+3")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "8/8/8/8/8/4K3/8/4k2R b - - 0 40", options.FEN, "should be equal")
+	assert.NotNil(t, options.TimeControl, "should not be nil")
+
+	_, err = ParseRoomOptions("room+fen=8/8/8/8/8/8/4K3/8_w_-_-_0_1")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_StartGame_Should_Give_The_Side_To_Move_To_The_Room_Creator_When_Started_From_A_FEN(t *testing.T) {
+	options := RoomOptions{Name: "test", FEN: "8/8/8/8/8/8/4K3/k6R b - - 0 40"}
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, "test", options, blanklogger.NewBlankLogger())
+	assert.Nil(t, err, "should be nil")
+
+	creator, opponent := newTestPlayer(White), newTestPlayer(White)
+	opponent.CreatedAt = creator.CreatedAt.Add(time.Second)
+	addTestPlayers(g, creator, opponent)
+	g.startGame()
+
+	assert.Equal(t, Black, creator.PlayerColor, "should be equal")
+	assert.True(t, creator.IsActive, "should be true")
+	assert.Equal(t, White, opponent.PlayerColor, "should be equal")
+	assert.False(t, opponent.IsActive, "should be false")
+
+	playMoves(g, opponent, creator, "a1b2")
+	assert.Contains(t, g.PGN(), "[FEN \"8/8/8/8/8/8/4K3/k6R b - - 0 40\"]", "should contain the FEN")
+	assert.Contains(t, g.PGN(), "40... Kb2", "should start at the FEN's move number")
+	assert.Equal(t, chess.White, g.Model.Position().Turn(), "should be white's turn")
+}
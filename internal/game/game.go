@@ -2,16 +2,22 @@ package game
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"io"
 	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/n7down/ssh-chess/internal/logger"
 
 	aurora "github.com/logrusorgru/aurora"
 	chess "github.com/notnil/chess"
+	"github.com/notnil/chess/opening"
 	uuid "github.com/satori/go.uuid"
 )
 
@@ -20,6 +26,7 @@ type BoardColor int
 const (
 	Red BoardColor = iota
 	Green
+	Yellow
 	None
 )
 
@@ -34,65 +41,130 @@ const (
 )
 
 type Game struct {
-	userCreatedGame bool
-	Name            string
-	Redraw          chan struct{}
-	level           [][]string
-	hub             Hub
-	board           [][]string
-	started         bool
-	boardColors     map[Position]BoardColor
-	mutex           sync.RWMutex
-	Model           *chess.Game
-	startTime       time.Time
-	id              string
-	logger          logger.Logger
+	userCreatedGame      bool
+	Name                 string
+	Redraw               chan struct{}
+	level                [][]string
+	hub                  Hub
+	started              bool
+	boardColors          map[Position]BoardColor
+	mutex                sync.RWMutex
+	stateMutex           sync.Mutex
+	Model                *chess.Game
+	history              []*chess.Game
+	record               []string
+	uciRecord            []string
+	chess960             bool
+	chess960Index        int
+	castlingRooks        []chess.Square
+	castlingRooksHistory [][]chess.Square
+	drops                map[int]pieceDrop
+	variant              Variant
+	bots                 []*Player
+	botWait              time.Duration
+	reconnectGrace       time.Duration
+	held                 map[*Player]heldSeat
+	heldMutex            sync.Mutex
+	dropped              chan *Session
+	leave                func(*Session)
+	newBot               NewBotFunc
+	newHintBot           func() Bot
+	hintMoves            chan botMove
+	hintPlayer           *Player
+	hint                 *hint
+	hints                map[chess.Color]int
+	newAnalyzer          func() Analyzer
+	analysisUpdates      chan analysisUpdate
+	showAnalysis         bool
+	analysisDone         int
+	analysisTotal        int
+	analysis             *analysis
+	analysisErr          error
+	puzzle               *puzzleState
+	drill                *drillState
+	opening              *opening.Opening
+	options              RoomOptions
+	random               *rand.Rand
+	clock                *Clock
+	ended                bool
+	outcome              chess.Outcome
+	method               string
+	startTime            time.Time
+	endTime              time.Time
+	id                   string
+	logger               logger.Logger
 }
 
 func NewGame(worldWidth, worldHeight int, name string, logger logger.Logger) *Game {
-	g := &Game{
-		userCreatedGame: false,
-		Name:            name,
-		Redraw:          make(chan struct{}),
-		hub:             NewHub(),
-		Model:           chess.NewGame(chess.UseNotation(chess.LongAlgebraicNotation{})),
-		logger:          logger,
-	}
+	return newGame(worldWidth, worldHeight, name, false, RoomOptions{Name: name}, logger)
+}
 
-	id := uuid.NewV4()
-	g.id = id.String()
+// NewUserCreatedGame returns a game for a named room, an error is returned when
+// the game can not be set up from the room's options
+func NewUserCreatedGame(worldWidth, worldHeight int, name string, options RoomOptions, logger logger.Logger) (*Game, error) {
+	g := newGame(worldWidth, worldHeight, name, true, options, logger)
 
-	g.mutex = sync.RWMutex{}
+	if options.Chess960 {
+		if err := g.setupChess960(options.Chess960Index); err != nil {
+			return nil, err
+		}
+	}
 
-	g.started = false
+	if options.FEN != "" {
+		if err := g.setupFEN(options.FEN); err != nil {
+			return nil, err
+		}
+	}
 
-	g.initializeColors()
-	g.initializeBoard()
-	g.initializeLevel(worldWidth, worldHeight)
-	g.SetBoardColorsSelectingPiece(Position{0, 0}, White)
-	g.drawBoard(worldWidth, worldHeight)
+	if err := g.setupOdds(); err != nil {
+		return nil, err
+	}
 
-	return g
+	variant, err := NewVariant(options.Variant)
+	if err != nil {
+		return nil, err
+	}
+	if err := variant.Setup(g); err != nil {
+		return nil, err
+	}
+	g.variant = variant
+	return g, nil
 }
 
-func NewUserCreatedGame(worldWidth, worldHeight int, name string) *Game {
+func newGame(worldWidth, worldHeight int, name string, userCreatedGame bool, options RoomOptions, logger logger.Logger) *Game {
 	g := &Game{
-		userCreatedGame: true,
+		userCreatedGame: userCreatedGame,
 		Name:            name,
 		Redraw:          make(chan struct{}),
 		hub:             NewHub(),
 		Model:           chess.NewGame(chess.UseNotation(chess.LongAlgebraicNotation{})),
+		options:         options,
+		outcome:         chess.NoOutcome,
+		drops:           map[int]pieceDrop{},
+		hintMoves:       make(chan botMove, 1),
+		hints:           map[chess.Color]int{},
+		held:            map[*Player]heldSeat{},
+		dropped:         make(chan *Session),
+		analysisUpdates: make(chan analysisUpdate, 1),
+		variant:         Standard{},
+		random:          rand.New(rand.NewSource(time.Now().UnixNano())),
+		logger:          logger,
 	}
 
 	id := uuid.NewV4()
 	g.id = id.String()
+	g.leave = g.Leave
 
 	g.mutex = sync.RWMutex{}
 
 	g.started = false
 
+	if options.TimeControl != nil {
+		g.clock = NewClock(*options.TimeControl)
+		g.Model.AddTagPair("TimeControl", options.TimeControl.PGNString())
+	}
+
 	g.initializeColors()
-	g.initializeBoard()
 	g.initializeLevel(worldWidth, worldHeight)
 	g.SetBoardColorsSelectingPiece(Position{0, 0}, White)
 	g.drawBoard(worldWidth, worldHeight)
@@ -104,64 +176,40 @@ func (g *Game) getColor(p Position, s string) string {
 	g.mutex.RLock()
 	c := g.boardColors[p]
 	g.mutex.RUnlock()
+	return colorString(c, s)
+}
+
+// colorString returns the string in the board color
+func colorString(c BoardColor, s string) string {
 	switch c {
 	case Red:
 		return aurora.Sprintf(aurora.Red(s))
 	case Green:
 		return aurora.Sprintf(aurora.Green(s))
+	case Yellow:
+		return aurora.Sprintf(aurora.Yellow(s))
 	}
 	return s
 }
 
 func (g *Game) SetBoardColorsSelectingPiece(playerPosition Position, chessPiecesColor ChessPiecesColor) {
-	charOver := g.board[playerPosition.x][playerPosition.y]
-
-	g.resetBoardColors()
-
-	if chessPiecesColor == White {
-		if charOver == whitePawn || charOver == whiteRook ||
-			charOver == whiteKnight || charOver == whiteBishop ||
-			charOver == whiteKing || charOver == whiteQueen {
+	pieceOver := g.pieceAt(playerPosition)
 
-			g.mutex.Lock()
-			g.boardColors[Position{playerPosition.x, playerPosition.y}] = Green
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y}] = Green
-			g.boardColors[Position{playerPosition.x, playerPosition.y + 1}] = Green
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y + 1}] = Green
-			g.mutex.Unlock()
-		} else {
-			g.mutex.Lock()
-			g.boardColors[Position{playerPosition.x, playerPosition.y}] = Red
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y}] = Red
-			g.boardColors[Position{playerPosition.x, playerPosition.y + 1}] = Red
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y + 1}] = Red
-			g.mutex.Unlock()
-		}
+	if pieceOver != chess.NoPiece && pieceOver.Color() == chessPiecesColor.modelColor() {
+		g.SetPositionColor(playerPosition, Green)
 	} else {
-		if charOver == blackPawn || charOver == blackRook ||
-			charOver == blackKnight || charOver == blackBishop ||
-			charOver == blackKing || charOver == blackQueen {
-
-			g.mutex.Lock()
-			g.boardColors[Position{playerPosition.x, playerPosition.y}] = Green
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y}] = Green
-			g.boardColors[Position{playerPosition.x, playerPosition.y + 1}] = Green
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y + 1}] = Green
-			g.mutex.Unlock()
-		} else {
-			g.mutex.Lock()
-			g.boardColors[Position{playerPosition.x, playerPosition.y}] = Red
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y}] = Red
-			g.boardColors[Position{playerPosition.x, playerPosition.y + 1}] = Red
-			g.boardColors[Position{playerPosition.x + 1, playerPosition.y + 1}] = Red
-			g.mutex.Unlock()
-		}
+		g.SetPositionColor(playerPosition, Red)
 	}
 }
 
 func (g *Game) SetPositionColor(playerPosition Position, boardColor BoardColor) {
 	g.resetBoardColors()
+	g.setHintColors()
+	g.setSquareColor(playerPosition, boardColor)
+}
 
+// setSquareColor colors the corners of the square at the position
+func (g *Game) setSquareColor(playerPosition Position, boardColor BoardColor) {
 	g.mutex.Lock()
 	g.boardColors[Position{playerPosition.x, playerPosition.y}] = boardColor
 	g.boardColors[Position{playerPosition.x + 1, playerPosition.y}] = boardColor
@@ -192,18 +240,70 @@ func (g *Game) initializeLevel(width int, height int) {
 	}
 }
 
-func (g *Game) initializeBoard() {
-	// this is the board that the players change
-	g.board = [][]string{
-		{whiteRook, whitePawn, " ", " ", " ", " ", blackPawn, blackRook},
-		{whiteKnight, whitePawn, " ", " ", " ", " ", blackPawn, blackKnight},
-		{whiteBishop, whitePawn, " ", " ", " ", " ", blackPawn, blackBishop},
-		{whiteQueen, whitePawn, " ", " ", " ", " ", blackPawn, blackQueen},
-		{whiteKing, whitePawn, " ", " ", " ", " ", blackPawn, blackKing},
-		{whiteBishop, whitePawn, " ", " ", " ", " ", blackPawn, blackBishop},
-		{whiteKnight, whitePawn, " ", " ", " ", " ", blackPawn, blackKnight},
-		{whiteRook, whitePawn, " ", " ", " ", " ", blackPawn, blackRook},
+// setupFEN starts the game from the position in the FEN
+func (g *Game) setupFEN(fen string) error {
+	if err := validateFEN(fen); err != nil {
+		return err
+	}
+
+	startingPosition, err := chess.FEN(fen)
+	if err != nil {
+		return err
+	}
+	g.Model = g.newModel(startingPosition)
+	g.Model.AddTagPair("SetUp", "1")
+	g.Model.AddTagPair("FEN", fen)
+	return nil
+}
+
+// newModel returns a model that starts from the position and keeps the game's
+// tags, a new model is started when the model does not know the move played
+func (g *Game) newModel(startingPosition func(*chess.Game)) *chess.Game {
+	return chess.NewGame(
+		startingPosition,
+		chess.TagPairs(g.Model.TagPairs()),
+		chess.UseNotation(chess.LongAlgebraicNotation{}),
+	)
+}
+
+// pieceAt returns the piece from the model that is on the position
+func (g *Game) pieceAt(p Position) chess.Piece {
+	return g.Model.Position().Board().Piece(p.positionToSquare())
+}
+
+// capturedPiece returns the piece that will be taken by the move
+func (g *Game) capturedPiece(move *chess.Move) chess.Piece {
+	return capturedPiece(g.Model.Position().Board(), move)
+}
+
+// capturedPiece returns the piece on the board that is taken by the move, en
+// passant captures take the pawn beside the square that is moved to
+func capturedPiece(board *chess.Board, move *chess.Move) chess.Piece {
+	if move.HasTag(chess.EnPassant) {
+		return board.Piece(chess.Square(int(move.S1().Rank())*8 + int(move.S2().File())))
 	}
+	return board.Piece(move.S2())
+}
+
+// capturedPieces returns the pieces taken by the color over the whole game
+func (g *Game) capturedPieces(color chess.Color) []string {
+	takenPieces := []string{}
+	for _, model := range g.models() {
+		positions := model.Positions()
+		for i, move := range model.Moves() {
+			if positions[i].Turn() != color {
+				continue
+			}
+			if piece := capturedPiece(positions[i].Board(), move); piece != chess.NoPiece {
+				takenPieces = append(takenPieces, modelPieceString(piece))
+			}
+		}
+	}
+	return takenPieces
+}
+
+func (g *Game) pieceString(p Position) string {
+	return modelPieceString(g.pieceAt(p))
 }
 
 func (g *Game) drawBoard(width, height int) {
@@ -250,21 +350,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"A",
 			"-",
-			g.board[0][0],
+			g.pieceString(Position{0, 0}),
 			"-",
-			g.board[0][1],
+			g.pieceString(Position{0, 1}),
 			"-",
-			g.board[0][2],
+			g.pieceString(Position{0, 2}),
 			"-",
-			g.board[0][3],
+			g.pieceString(Position{0, 3}),
 			"-",
-			g.board[0][4],
+			g.pieceString(Position{0, 4}),
 			"-",
-			g.board[0][5],
+			g.pieceString(Position{0, 5}),
 			"-",
-			g.board[0][6],
+			g.pieceString(Position{0, 6}),
 			"-",
-			g.board[0][7],
+			g.pieceString(Position{0, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -293,21 +393,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"B",
 			"-",
-			g.board[1][0],
+			g.pieceString(Position{1, 0}),
 			"-",
-			g.board[1][1],
+			g.pieceString(Position{1, 1}),
 			"-",
-			g.board[1][2],
+			g.pieceString(Position{1, 2}),
 			"-",
-			g.board[1][3],
+			g.pieceString(Position{1, 3}),
 			"-",
-			g.board[1][4],
+			g.pieceString(Position{1, 4}),
 			"-",
-			g.board[1][5],
+			g.pieceString(Position{1, 5}),
 			"-",
-			g.board[1][6],
+			g.pieceString(Position{1, 6}),
 			"-",
-			g.board[1][7],
+			g.pieceString(Position{1, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -336,21 +436,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"C",
 			"-",
-			g.board[2][0],
+			g.pieceString(Position{2, 0}),
 			"-",
-			g.board[2][1],
+			g.pieceString(Position{2, 1}),
 			"-",
-			g.board[2][2],
+			g.pieceString(Position{2, 2}),
 			"-",
-			g.board[2][3],
+			g.pieceString(Position{2, 3}),
 			"-",
-			g.board[2][4],
+			g.pieceString(Position{2, 4}),
 			"-",
-			g.board[2][5],
+			g.pieceString(Position{2, 5}),
 			"-",
-			g.board[2][6],
+			g.pieceString(Position{2, 6}),
 			"-",
-			g.board[2][7],
+			g.pieceString(Position{2, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -379,21 +479,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"D",
 			"-",
-			g.board[3][0],
+			g.pieceString(Position{3, 0}),
 			"-",
-			g.board[3][1],
+			g.pieceString(Position{3, 1}),
 			"-",
-			g.board[3][2],
+			g.pieceString(Position{3, 2}),
 			"-",
-			g.board[3][3],
+			g.pieceString(Position{3, 3}),
 			"-",
-			g.board[3][4],
+			g.pieceString(Position{3, 4}),
 			"-",
-			g.board[3][5],
+			g.pieceString(Position{3, 5}),
 			"-",
-			g.board[3][6],
+			g.pieceString(Position{3, 6}),
 			"-",
-			g.board[3][7],
+			g.pieceString(Position{3, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -422,21 +522,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"E",
 			"-",
-			g.board[4][0],
+			g.pieceString(Position{4, 0}),
 			"-",
-			g.board[4][1],
+			g.pieceString(Position{4, 1}),
 			"-",
-			g.board[4][2],
+			g.pieceString(Position{4, 2}),
 			"-",
-			g.board[4][3],
+			g.pieceString(Position{4, 3}),
 			"-",
-			g.board[4][4],
+			g.pieceString(Position{4, 4}),
 			"-",
-			g.board[4][5],
+			g.pieceString(Position{4, 5}),
 			"-",
-			g.board[4][6],
+			g.pieceString(Position{4, 6}),
 			"-",
-			g.board[4][7],
+			g.pieceString(Position{4, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -465,21 +565,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"F",
 			"-",
-			g.board[5][0],
+			g.pieceString(Position{5, 0}),
 			"-",
-			g.board[5][1],
+			g.pieceString(Position{5, 1}),
 			"-",
-			g.board[5][2],
+			g.pieceString(Position{5, 2}),
 			"-",
-			g.board[5][3],
+			g.pieceString(Position{5, 3}),
 			"-",
-			g.board[5][4],
+			g.pieceString(Position{5, 4}),
 			"-",
-			g.board[5][5],
+			g.pieceString(Position{5, 5}),
 			"-",
-			g.board[5][6],
+			g.pieceString(Position{5, 6}),
 			"-",
-			g.board[5][7],
+			g.pieceString(Position{5, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -508,21 +608,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"G",
 			"-",
-			g.board[6][0],
+			g.pieceString(Position{6, 0}),
 			"-",
-			g.board[6][1],
+			g.pieceString(Position{6, 1}),
 			"-",
-			g.board[6][2],
+			g.pieceString(Position{6, 2}),
 			"-",
-			g.board[6][3],
+			g.pieceString(Position{6, 3}),
 			"-",
-			g.board[6][4],
+			g.pieceString(Position{6, 4}),
 			"-",
-			g.board[6][5],
+			g.pieceString(Position{6, 5}),
 			"-",
-			g.board[6][6],
+			g.pieceString(Position{6, 6}),
 			"-",
-			g.board[6][7],
+			g.pieceString(Position{6, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -551,21 +651,21 @@ func (g *Game) drawBoard(width, height int) {
 		{
 			"H",
 			"-",
-			g.board[7][0],
+			g.pieceString(Position{7, 0}),
 			"-",
-			g.board[7][1],
+			g.pieceString(Position{7, 1}),
 			"-",
-			g.board[7][2],
+			g.pieceString(Position{7, 2}),
 			"-",
-			g.board[7][3],
+			g.pieceString(Position{7, 3}),
 			"-",
-			g.board[7][4],
+			g.pieceString(Position{7, 4}),
 			"-",
-			g.board[7][5],
+			g.pieceString(Position{7, 5}),
 			"-",
-			g.board[7][6],
+			g.pieceString(Position{7, 6}),
 			"-",
-			g.board[7][7],
+			g.pieceString(Position{7, 7}),
 			"-",
 		},
 		{" ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-", " ", "-"},
@@ -604,12 +704,24 @@ func (g *Game) drawBoard(width, height int) {
 	}
 }
 
+// players returns the game's players and their sessions, bots and players
+// whose seats are held do not have a session
 func (g *Game) players() map[*Player]*Session {
 	players := make(map[*Player]*Session)
 
+	for player := range g.heldPlayers() {
+		players[player] = nil
+	}
+
+	g.hub.sessionsMutex.RLock()
 	for session := range g.hub.Sessions {
 		players[session.Player] = session
 	}
+	g.hub.sessionsMutex.RUnlock()
+
+	for _, bot := range g.bots {
+		players[bot] = nil
+	}
 
 	return players
 }
@@ -618,23 +730,393 @@ func (g *Game) CheckGameState() {
 
 	g.logger.Debug("checking game state")
 
-	var err error
-	if g.Model.Outcome() != chess.NoOutcome {
+	if outcome, method := g.variant.Outcome(g); outcome != chess.NoOutcome {
+		g.endGame(outcome, method)
+	}
+}
+
+// modelOutcome returns the outcome found by the model such as checkmate or a
+// draw by insufficient material
+func modelOutcome(g *Game) (chess.Outcome, string) {
+	return g.Model.Outcome(), methodString(g.Model.Method())
+}
+
+// validMoves returns the moves that can be played under the game's variant
+func (g *Game) validMoves() []*chess.Move {
+	return g.variant.ValidMoves(g, g.Model.ValidMoves())
+}
+
+// moveColor returns the color that played the move at the index in the record
+func (g *Game) moveColor(i int) chess.Color {
+	firstColor := g.models()[0].Positions()[0].Turn()
+	if i%2 == 0 {
+		return firstColor
+	}
+	return firstColor.Other()
+}
+
+// checkClock ends the game when a player runs out of time. The player with
+// time left wins unless they do not have enough material to checkmate
+func (g *Game) checkClock() {
+	flaggedColor, flagged := g.clock.Flagged()
+	if !flagged {
+		return
+	}
 
-		outcome := g.Model.Outcome().String()
-		gameMessage := fmt.Sprintf("game is over. %s by %s\ngame string: %s", outcome, g.Model.Method(), g.Model.String())
-		for s := range g.hub.Sessions {
-			unregisterMessage := UnregisterMessage{
-				session: s,
-				message: gameMessage,
+	g.logger.Debug(fmt.Sprintf("flag fall: %v", flaggedColor.modelColor().Name()))
+
+	opponentColor := flaggedColor.modelColor().Other()
+	if !hasMatingMaterial(g.Model.Position().Board(), opponentColor) {
+		g.endGame(chess.Draw, "timeout vs insufficient material")
+	} else if opponentColor == chess.White {
+		g.endGame(chess.WhiteWon, "time forfeit")
+	} else {
+		g.endGame(chess.BlackWon, "time forfeit")
+	}
+}
+
+// claimableDraws returns the draws a player can claim, draws by threefold
+// repetition and the fifty-move rule are not made automatically
+func (g *Game) claimableDraws() []chess.Method {
+	claimableDraws := []chess.Method{}
+	for _, method := range g.Model.EligibleDraws() {
+		if method == chess.ThreefoldRepetition || method == chess.FiftyMoveRule {
+			claimableDraws = append(claimableDraws, method)
+		}
+	}
+	return claimableDraws
+}
+
+// Resign ends the game with the player's opponent as the winner
+func (g *Game) Resign(p *Player) {
+	if g.puzzle != nil {
+		// giving up fails the puzzle but the player can go on to the next one
+		if !g.puzzle.done {
+			g.finishPuzzle(p, false, fmt.Sprintf("the solution was %s.", puzzleSolution(g.Model.Position(), g.puzzle.puzzle.Moves[g.puzzle.next])))
+		}
+		return
+	}
+	g.logger.Debug(fmt.Sprintf("%s resigned", p.Name))
+	g.Model.Resign(p.PlayerColor.modelColor())
+	g.endGame(winner(p.PlayerColor.modelColor().Other()), methodString(chess.Resignation))
+}
+
+// Takeback undoes the player's last move. When it is already the player's turn
+// the opponent's reply is undone as well
+func (g *Game) Takeback(p *Player) error {
+	undo := 1
+	if g.Model.Position().Turn() == p.PlayerColor.modelColor() {
+		undo = 2
+	}
+	if undo > len(g.record) {
+		return errors.New("there are no moves to take back")
+	}
+
+	g.logger.Debug(fmt.Sprintf("taking back %d moves for %s", undo, p.Name))
+
+	for i := 0; i < undo; i++ {
+		if err := g.undoMove(); err != nil {
+			return err
+		}
+	}
+
+	g.updateTakenPieces()
+	g.syncActivePlayer()
+	return nil
+}
+
+// updateTakenPieces sets the pieces shown beside the board for each player
+func (g *Game) updateTakenPieces() {
+	for player := range g.players() {
+		player.TakenPiecesList = g.variant.TakenPieces(g, player.PlayerColor.modelColor())
+	}
+}
+
+// makeMove plays the move in the model and records it in the game's record
+func (g *Game) makeMove(move *chess.Move) error {
+	var validMove *chess.Move
+	for _, m := range g.validMoves() {
+		if m.S1() == move.S1() && m.S2() == move.S2() && m.Promo() == move.Promo() {
+			validMove = m
+		}
+	}
+	if validMove == nil {
+		return fmt.Errorf("invalid move: %s", move)
+	}
+
+	position := g.Model.Position()
+	san := chess.AlgebraicNotation{}.Encode(position, validMove)
+	if err := g.Model.Move(validMove); err != nil {
+		return err
+	}
+
+	// a checkmate in the model is only a check when the variant lets the game go on
+	if outcome, _ := g.variant.Outcome(g); strings.HasSuffix(san, "#") && outcome == chess.NoOutcome {
+		san = strings.TrimSuffix(san, "#") + "+"
+	}
+
+	g.updateCastlingRights(position.Board(), validMove)
+	g.record = append(g.record, san)
+	g.uciRecord = append(g.uciRecord, chess.UCINotation{}.Encode(position, validMove))
+	return nil
+}
+
+// moveMade passes the turn to the other player after a move
+func (g *Game) moveMade() {
+	if g.puzzle != nil {
+		for player := range g.players() {
+			g.puzzleMoveMade(player)
+		}
+		return
+	}
+
+	g.updateOpening()
+	if g.clock != nil {
+		g.clock.Press()
+	}
+
+	g.declinePendingOffers(g.Model.Position().Turn().Other())
+	g.updateTakenPieces()
+
+	if g.drill != nil && g.checkDrill() {
+		return
+	}
+	g.CheckGameState()
+	g.SwitchPlayersIsActive()
+}
+
+// declinePendingOffers declines the draw offer or takeback request of the
+// player who moved instead of answering it
+func (g *Game) declinePendingOffers(mover chess.Color) {
+	for player := range g.players() {
+		if player.PlayerColor.modelColor() != mover {
+			continue
+		}
+		if player.Prompt == DrawOfferPrompt || player.Prompt == TakebackPrompt {
+			player.answerPrompt(g, false)
+		}
+	}
+}
+
+// undoMove takes back the last move in the game's record
+func (g *Game) undoMove() error {
+	if len(g.record) == 0 {
+		return errors.New("there are no moves to take back")
+	}
+
+	moves := g.Model.Moves()
+	if len(moves) == 0 {
+		// the model starts after the last move so go back to the model before it
+		g.Model = g.history[len(g.history)-1]
+		g.history = g.history[:len(g.history)-1]
+	} else {
+		startingPosition, err := chess.FEN(g.Model.Positions()[0].String())
+		if err != nil {
+			return err
+		}
+		model := g.newModel(startingPosition)
+		for _, move := range moves[:len(moves)-1] {
+			if err := model.Move(move); err != nil {
+				return err
 			}
-			g.hub.Unregister <- unregisterMessage
 		}
+		g.Model = model
 	}
 
-	if err != nil {
-		g.logger.Debug(fmt.Sprintf("error sending data: %v", err.Error()))
+	delete(g.drops, len(g.record)-1)
+	g.record = g.record[:len(g.record)-1]
+	g.uciRecord = g.uciRecord[:len(g.uciRecord)-1]
+	g.castlingRooks = g.castlingRooksHistory[len(g.castlingRooksHistory)-1]
+	g.castlingRooksHistory = g.castlingRooksHistory[:len(g.castlingRooksHistory)-1]
+	g.updateOpening()
+	return nil
+}
+
+// models returns the models the game has been played in, a new model is
+// started after moves the model does not know such as chess960 castling
+func (g *Game) models() []*chess.Game {
+	return append(append([]*chess.Game(nil), g.history...), g.Model)
+}
+
+// syncActivePlayer makes the player whose turn it is in the model the active
+// player and puts back any piece that was being placed
+func (g *Game) syncActivePlayer() {
+	turn := colorFromModel(g.Model.Position().Turn())
+	for player := range g.players() {
+		player.PlayerState = SelectingPiece
+		player.SelectedPiecePosition = &Position{-1, -1}
+		player.IsActive = player.PlayerColor == turn
+		if player.IsActive {
+			g.SetBoardColorsSelectingPiece(*player.BoardPosition, player.PlayerColor)
+		}
+	}
+
+	if g.clock != nil {
+		g.clock.Stop()
+		g.clock.Start(turn)
+	}
+}
+
+// Leave removes the session from the game, a player that leaves a game in
+// progress resigns
+func (g *Game) Leave(s *Session) {
+	if g.ended {
+		g.RemoveSession(s, g.gameOverMessage())
+		return
+	}
+	if g.InProgress() {
+		g.Resign(s.Player)
 	}
+	g.RemoveSession(s, "you left the game")
+}
+
+// InProgress returns true when the game has started and has not ended
+func (g *Game) InProgress() bool {
+	return g.started && !g.ended
+}
+
+func (g *Game) Ended() bool {
+	return g.ended
+}
+
+// endGame records the outcome and sends it to the players
+func (g *Game) endGame(outcome chess.Outcome, method string) {
+	if g.ended {
+		return
+	}
+	g.ended = true
+	g.outcome = outcome
+	g.method = method
+
+	if g.clock != nil {
+		g.clock.Stop()
+	}
+
+	g.Model.AddTagPair("Result", outcome.String())
+	g.Model.AddTagPair("Termination", method)
+	g.addOpeningTags()
+
+	g.endTime = time.Now()
+
+	// players look at the analysis of the game before they are disconnected
+	analyze := g.newAnalyzer != nil && len(g.record) > 0
+	if analyze {
+		g.startAnalysis()
+	}
+
+	gameMessage := g.gameOverMessage()
+	for player, session := range g.players() {
+		if session == nil {
+			continue
+		}
+		if session.protocol != nil {
+			g.RemoveSession(session, session.protocol.end(g, player))
+		} else if !analyze {
+			g.RemoveSession(session, gameMessage)
+		}
+	}
+}
+
+// gameOverMessage returns the outcome and the PGN shown when a player leaves a
+// finished game
+func (g *Game) gameOverMessage() string {
+	return fmt.Sprintf("game over: %s by %s\r\n\r\n%s", outcomeString(g.outcome), g.method, strings.ReplaceAll(g.PGN(), "\n", "\r\n"))
+}
+
+// PGN returns the game's PGN including moves and outcomes that are not
+// recorded by the model such as chess960 castling, drops and time forfeits
+func (g *Game) PGN() string {
+	buffer := bytes.Buffer{}
+	for _, tagPair := range append(g.Model.TagPairs(), g.hintTagPairs()...) {
+		buffer.WriteString(fmt.Sprintf("[%s \"%s\"]\n", tagPair.Key, tagPair.Value))
+	}
+	buffer.WriteString("\n")
+
+	fields := strings.Fields(g.models()[0].Positions()[0].String())
+	moveNumber, _ := strconv.Atoi(fields[5])
+	blackToMove := fields[1] == "b"
+	for i, san := range g.record {
+		if i == 0 && blackToMove {
+			buffer.WriteString(fmt.Sprintf("%d... ", moveNumber))
+		} else if (i%2 == 0) != blackToMove {
+			buffer.WriteString(fmt.Sprintf("%d. ", moveNumber))
+		}
+		buffer.WriteString(san + " ")
+		if g.analysis != nil && g.analysis.moves[i].judgement != goodMove {
+			move := g.analysis.moves[i]
+			buffer.WriteString(fmt.Sprintf("%s {%s} ", move.judgement.nag(), move.comment()))
+		}
+		if (i%2 == 0) == blackToMove {
+			moveNumber++
+		}
+	}
+
+	buffer.WriteString(g.outcome.String())
+	return buffer.String()
+}
+
+func outcomeString(outcome chess.Outcome) string {
+	switch outcome {
+	case chess.WhiteWon:
+		return "white wins"
+	case chess.BlackWon:
+		return "black wins"
+	case chess.Draw:
+		return "draw"
+	}
+	return "no result"
+}
+
+func methodString(method chess.Method) string {
+	switch method {
+	case chess.Checkmate:
+		return "checkmate"
+	case chess.Resignation:
+		return "resignation"
+	case chess.DrawOffer:
+		return "agreement"
+	case chess.Stalemate:
+		return "stalemate"
+	case chess.ThreefoldRepetition:
+		return "threefold repetition"
+	case chess.FivefoldRepetition:
+		return "fivefold repetition"
+	case chess.FiftyMoveRule:
+		return "the fifty-move rule"
+	case chess.SeventyFiveMoveRule:
+		return "the seventy-five-move rule"
+	case chess.InsufficientMaterial:
+		return "insufficient material"
+	}
+	return method.String()
+}
+
+// hasMatingMaterial returns false when the color only has a king or a king and
+// a single minor piece
+func hasMatingMaterial(board *chess.Board, color chess.Color) bool {
+	minorPieces := 0
+	for _, piece := range board.SquareMap() {
+		if piece.Color() != color {
+			continue
+		}
+		switch piece.Type() {
+		case chess.King:
+		case chess.Bishop, chess.Knight:
+			minorPieces++
+		default:
+			return true
+		}
+	}
+	return minorPieces > 1
+}
+
+func (g *Game) opponent(p *Player) *Player {
+	for player := range g.players() {
+		if player != p {
+			return player
+		}
+	}
+	return nil
 }
 
 func (g *Game) SwitchPlayersIsActive() {
@@ -709,31 +1191,14 @@ func (g *Game) roomString(s *Session) string {
 		strWorld[x][y] = string(p)
 	}
 
-	// TODO: show if a piece is being placed
 	// Draw the player's name
-	playerChessPiecesColor := s.Player.PlayerColor
-	playerIsActive := s.Player.IsActive
-	playerState := s.Player.PlayerState
-	playerName := s.Player.Name
-
-	var playerNameToDisplay string
-	if playerState == PlacingPiece {
-		boardCoords := s.Player.SelectedPiecePosition.positionToModel()
-		if playerIsActive {
-			playerNameToDisplay = fmt.Sprintf(" [ %s%s %s ] ", playerChessPiecesColor, playerName, boardCoords)
-		} else {
-			playerNameToDisplay = fmt.Sprintf(" %s%s %s", playerChessPiecesColor, playerName, boardCoords)
-		}
-	} else {
-		if playerIsActive {
-			playerNameToDisplay = fmt.Sprintf(" [ %s%s ] ", playerChessPiecesColor, playerName)
-		} else {
-			playerNameToDisplay = fmt.Sprintf(" %s%s ", playerChessPiecesColor, playerName)
-		}
-	}
+	playerNameToDisplay := g.playerString(s.Player)
+
+	drawText(strWorld, 3, worldHeight+1, playerNameToDisplay)
 
-	for i, r := range playerNameToDisplay {
-		strWorld[3+i][worldHeight+1] = string(r)
+	// draw the promotion picker
+	if picker := g.pickerString(s.Player); picker != "" {
+		drawText(strWorld, 3, worldHeight-1, picker)
 	}
 
 	// Draw opponents name to the left of the players name
@@ -743,30 +1208,8 @@ func (g *Game) roomString(s *Session) string {
 				continue
 			}
 
-			opponentName := player.Name
-			opponentChessPiecesColor := player.PlayerColor
-			opponentIsActive := player.IsActive
-			opponentPlayerState := player.PlayerState
-
-			var opponent string
-			if opponentPlayerState == PlacingPiece {
-				boardCoords := player.SelectedPiecePosition.positionToModel()
-				if opponentIsActive {
-					opponent = fmt.Sprintf(" [ %s%s %s ] ", opponentChessPiecesColor, opponentName, boardCoords)
-				} else {
-					opponent = fmt.Sprintf(" %s%s %s", opponentChessPiecesColor, opponentName, boardCoords)
-				}
-			} else {
-				if opponentIsActive {
-					opponent = fmt.Sprintf(" [ %s%s ] ", opponentChessPiecesColor, opponentName)
-				} else {
-					opponent = fmt.Sprintf(" %s%s ", opponentChessPiecesColor, opponentName)
-				}
-			}
-			for i, r := range opponent {
-				charsRemaining := len(opponent) - i
-				strWorld[len(strWorld)-3-charsRemaining][len(strWorld[0])-1] = string(r)
-			}
+			opponent := g.playerString(player)
+			drawText(strWorld, len(strWorld)-3-utf8.RuneCountInString(opponent), len(strWorld[0])-1, opponent)
 		}
 	}
 
@@ -792,8 +1235,12 @@ func (g *Game) roomString(s *Session) string {
 	}
 
 	// Draw the game's name
-	nameStr := fmt.Sprintf(" %s ", g.Name)
-	for i, r := range nameStr {
+	nameStr := g.headerString(s.Player)
+	// long names are cut off before the corner of the board
+	for i, r := range []rune(nameStr) {
+		if 3+i >= len(strWorld)-3 {
+			break
+		}
 		strWorld[3+i][0] = string(r)
 	}
 
@@ -814,6 +1261,123 @@ func (g *Game) roomString(s *Session) string {
 
 }
 
+// headerString returns the game's name and what kind of game it is, such as
+// the time control, the variant and the opening
+func (g *Game) headerString(p *Player) string {
+	nameStr := fmt.Sprintf(" %s ", g.Name)
+	if g.options.TimeControl != nil {
+		nameStr = fmt.Sprintf(" %s (%s) ", g.Name, g.options.TimeControl)
+	}
+	if g.chess960 {
+		nameStr += fmt.Sprintf("chess960 #%d ", g.chess960Index)
+	}
+	if _, ok := g.variant.(Standard); !ok {
+		nameStr += g.variant.Name() + " "
+		if status := g.variant.Status(g); status != "" {
+			nameStr += status + " "
+		}
+	}
+	if odds := g.oddsString(); odds != "" {
+		nameStr += odds + " "
+	}
+	if opening := g.openingString(); opening != "" {
+		nameStr += opening + " "
+	}
+	if g.puzzle != nil {
+		nameStr = " " + g.puzzleString(p)
+	}
+	if g.drill != nil {
+		nameStr = g.drillString()
+	}
+	return nameStr
+}
+
+// pickerString returns the promotion or reserve picker while the player is
+// choosing a piece and the status otherwise
+func (g *Game) pickerString(p *Player) string {
+	switch p.PlayerState {
+	case PromotingPiece:
+		picker := " promote to:"
+		for i, promotionPiece := range promotionPieces {
+			if i == p.PromotionIndex {
+				picker += fmt.Sprintf(" [%s]", p.PlayerColor.pieceString(promotionPiece))
+			} else {
+				picker += fmt.Sprintf("  %s ", p.PlayerColor.pieceString(promotionPiece))
+			}
+		}
+		return picker
+	case SelectingReserve:
+		picker := " drop:"
+		for i, pieceType := range g.reservePieceTypes(p.PlayerColor.modelColor()) {
+			if i == p.ReserveIndex {
+				picker += fmt.Sprintf(" [%s]", p.PlayerColor.pieceString(pieceType))
+			} else {
+				picker += fmt.Sprintf("  %s ", p.PlayerColor.pieceString(pieceType))
+			}
+		}
+		return picker
+	case DroppingPiece:
+		return fmt.Sprintf(" dropping %s (e to cancel) ", p.PlayerColor.pieceString(p.DropPieceType))
+	}
+	if status := g.statusString(p); status != "" {
+		return " " + status + " "
+	}
+	return ""
+}
+
+// playerString returns the player's name, the square of the piece being placed
+// and the time left on the player's clock
+func (g *Game) playerString(p *Player) string {
+	var playerString string
+	if p.PlayerState == PlacingPiece || p.PlayerState == PromotingPiece {
+		boardCoords := p.SelectedPiecePosition.positionToModel()
+		if p.IsActive {
+			playerString = fmt.Sprintf(" [ %s%s %s ] ", p.PlayerColor, p.Name, boardCoords)
+		} else {
+			playerString = fmt.Sprintf(" %s%s %s", p.PlayerColor, p.Name, boardCoords)
+		}
+	} else {
+		if p.IsActive {
+			playerString = fmt.Sprintf(" [ %s%s ] ", p.PlayerColor, p.Name)
+		} else {
+			playerString = fmt.Sprintf(" %s%s ", p.PlayerColor, p.Name)
+		}
+	}
+
+	if g.clock != nil {
+		playerString += g.clock.String(p.PlayerColor) + " "
+	}
+	return playerString
+}
+
+// statusString returns the question or message shown to the player
+func (g *Game) statusString(p *Player) string {
+	if p.Prompt != NoPrompt {
+		return p.Prompt.String()
+	}
+	// the opponent's connection dropped
+	if reconnect := g.reconnectString(p); reconnect != "" {
+		return reconnect
+	}
+	if p.Message != "" {
+		return p.Message
+	}
+	if claimableDraws := g.claimableDraws(); g.InProgress() && len(claimableDraws) > 0 {
+		return fmt.Sprintf("draw by %s can be claimed (c)", methodString(claimableDraws[0]))
+	}
+	return g.tokenString(p)
+}
+
+// drawText writes text into the string slice one rune per cell starting at x, y
+func drawText(strWorld [][]string, x, y int, text string) {
+	for i, r := range []rune(text) {
+		if x+i >= len(strWorld) {
+			return
+		}
+		strWorld[x+i][y] = string(r)
+	}
+}
+
 func (g *Game) WorldWidth() int {
 	return len(g.level)
 }
@@ -823,22 +1387,62 @@ func (g *Game) WorldHeight() int {
 }
 
 func (g *Game) SessionCount() int {
+	g.hub.sessionsMutex.RLock()
+	defer g.hub.sessionsMutex.RUnlock()
 	return len(g.hub.Sessions)
 }
 
+// hasSession returns true when the session has not been removed from the game
+func (g *Game) hasSession(s *Session) bool {
+	g.hub.sessionsMutex.RLock()
+	defer g.hub.sessionsMutex.RUnlock()
+	_, ok := g.hub.Sessions[s]
+	return ok
+}
+
+// PlayerCount returns the number of players including bots
+func (g *Game) PlayerCount() int {
+	return len(g.players())
+}
+
 func (g *Game) startGame() {
-	rand.Seed(time.Now().UnixNano())
-	var randomBool bool
-	randomBool = rand.Float32() < 0.5
+	turn := colorFromModel(g.Model.Position().Turn())
 
+	players := []*Player{}
 	for player := range g.players() {
-		//fmt.Println(fmt.Sprintf("random bool: %v", randomBool))
-		g.logger.Debug(fmt.Sprintf("random bool: %v", randomBool))
-		player.SetIsActive(randomBool)
-		randomBool = !randomBool
+		players = append(players, player)
+	}
+
+	color := turn
+	if g.drill != nil {
+		sortDrillPlayers(players)
+	} else if g.options.FEN != "" || g.options.Odds != "" {
+		// the player who set up the position or gives the odds plays first
+		sort.Slice(players, func(i, j int) bool {
+			return players[i].CreatedAt.Before(players[j].CreatedAt)
+		})
+		if g.options.Odds != "" {
+			color = g.oddsGiverColor()
+			g.setupTimeOdds(color)
+		}
+	} else {
+		rand.Seed(time.Now().UnixNano())
+		rand.Shuffle(len(players), func(i, j int) {
+			players[i], players[j] = players[j], players[i]
+		})
+	}
+
+	for _, player := range players {
+		g.logger.Debug(fmt.Sprintf("%s plays %s", player.Name, color.modelColor().Name()))
+		player.SetColor(color)
+		player.IsActive = color == turn
+		color = 1 - color
 	}
 
 	g.startTime = time.Now()
+	if g.clock != nil {
+		g.clock.Start(turn)
+	}
 }
 
 func (g *Game) Run() {
@@ -852,11 +1456,13 @@ func (g *Game) Run() {
 
 	// Run game loop
 	go func() {
-		var lastUpdate time.Time
+		lastUpdate := time.Now()
 
 		c := time.Tick(time.Second / 60)
 		for now := range c {
+			g.stateMutex.Lock()
 			g.Update(float64(now.Sub(lastUpdate)) / float64(time.Millisecond))
+			g.stateMutex.Unlock()
 
 			lastUpdate = now
 		}
@@ -869,14 +1475,6 @@ func (g *Game) Run() {
 		c := time.Tick(time.Second / 10)
 		for range c {
 			g.Redraw <- struct{}{}
-
-			if g.started == false && len(g.players()) > 1 {
-				g.logger.Debug("starting game")
-
-				// start the game
-				g.startGame()
-				g.started = true
-			}
 		}
 	}()
 
@@ -886,15 +1484,59 @@ func (g *Game) Run() {
 // Update is the main game logic loop. Delta is the time since the last update
 // in milliseconds.
 func (g *Game) Update(delta float64) {
+	if g.started == false && len(g.players()) >= g.minPlayers() {
+		g.logger.Debug("starting game")
+
+		// start the game
+		g.startGame()
+		g.started = true
+	}
 
 	// Update player data
 	for player, _ := range g.players() {
-		player.Update(g, delta)
+		if player.IsBot() {
+			player.updateBot(g)
+		} else if player.s != nil && player.s.protocol != nil {
+			player.updateProtocol(g)
+		} else {
+			player.Update(g, delta)
+		}
+	}
+
+	g.offerBot()
+	g.updateDropped()
+	g.checkAbandoned()
+	g.updateHint()
+	g.updateAnalysis()
+
+	if g.started && !g.ended && g.clock != nil {
+		g.clock.Tick(time.Duration(delta * float64(time.Millisecond)))
+		g.checkClock()
 	}
 }
 
 func (g *Game) Render(s *Session) {
-	worldStr := g.roomString(s)
+	if s.protocol != nil {
+		s.protocol.render(g, s)
+		return
+	}
+
+	g.stateMutex.Lock()
+	size := s.TerminalSize()
+	var worldStr string
+	switch l := layoutFor(size); {
+	case l == tooSmallLayout:
+		worldStr = tooSmallString(size)
+	case g.showAnalysis:
+		worldStr = g.analysisString(s.Player, size)
+	case l == compactLayout:
+		worldStr = g.compactString(s, size)
+	case l == wideLayout || l == widestLayout:
+		worldStr = g.panelString(g.roomString(s), l)
+	default:
+		worldStr = g.roomString(s)
+	}
+	g.stateMutex.Unlock()
 
 	var b bytes.Buffer
 	b.WriteString("\033[H\033[2J")
@@ -910,6 +1552,9 @@ func (g *Game) AddSession(s *Session) {
 
 func (g *Game) RemoveSession(s *Session, msg string) {
 	message := "\r\n\r\n" + msg + "\r\n\r\n"
+	if s.protocol != nil {
+		message = msg
+	}
 	u := UnregisterMessage{
 		session: s,
 		message: message,
@@ -2,10 +2,15 @@ package game
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/n7down/ssh-chess/internal/auth"
 	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/n7down/ssh-chess/internal/puzzle"
 	"golang.org/x/crypto/ssh"
 
 	randomData "github.com/Pallinder/go-randomdata"
@@ -27,50 +32,163 @@ const (
 
 	keyF = 'f'
 
+	keyR = 'r'
+	keyO = 'o'
+	keyU = 'u'
+	keyC = 'c'
+	keyE = 'e'
+	keyI = 'i'
+	keyQ = 'q'
+
 	keyY = 'y'
 	keyN = 'n'
 
+	key1 = '1'
+	key2 = '2'
+	key3 = '3'
+	key4 = '4'
+	key5 = '5'
+
 	keyCtrlC = 3
 )
 
+// GameManagerConfig configures the games the game manager creates
+type GameManagerConfig struct {
+	// BotWait is how long a player waits for an opponent before being offered
+	// a bot game, a bot is never offered when it is zero
+	BotWait time.Duration
+	// NewBot returns the bot for the level the player chooses
+	NewBot NewBotFunc
+	// NewHintBot returns the bot that suggests moves for hints, hints are not
+	// given when it is nil
+	NewHintBot func() Bot
+	// NewAnalyzer returns the analyzer for the analysis shown after a game,
+	// games are not analysed when it is nil
+	NewAnalyzer func() Analyzer
+	// Puzzles are the puzzles for the puzzle command, the command is refused
+	// when it is nil
+	Puzzles *puzzle.Store
+	// ReconnectGrace is how long the seat of a player whose connection dropped
+	// is held, the opponent can claim the win after it. A player whose
+	// connection drops leaves the game when it is zero
+	ReconnectGrace time.Duration
+	// Version and Build are shown by the version command
+	Version string
+	Build   string
+}
+
 type GameManager struct {
 	UserCreatedGames map[string]*Game
 	Games            map[string]*Game
+	PuzzleGames      map[string]*Game
+	DrillGames       map[string]*Game
 	HandleChannel    chan ssh.Channel
+	config           GameManagerConfig
+	commands         map[string]execCommand
+	startTime        time.Time
 	logger           logger.Logger
+	// gamesMutex guards the maps of games, players connect and commands list
+	// the games from their own goroutines
+	gamesMutex sync.RWMutex
 }
 
-func NewGameManager(logger logger.Logger) *GameManager {
-	return &GameManager{
+func NewGameManager(config GameManagerConfig, logger logger.Logger) *GameManager {
+	gm := &GameManager{
 		UserCreatedGames: map[string]*Game{},
 		Games:            map[string]*Game{},
+		PuzzleGames:      map[string]*Game{},
+		DrillGames:       map[string]*Game{},
 		HandleChannel:    make(chan ssh.Channel),
+		config:           config,
+		startTime:        time.Now(),
 		logger:           logger,
 	}
+	gm.commands = gm.newExecCommands()
+	return gm
+}
+
+// configureGame gives the game the bots from the game manager's config, a
+// player leaving the game leaves through the game manager
+func (gm *GameManager) configureGame(g *Game) {
+	g.leave = func(s *Session) {
+		gm.leave(g, s)
+	}
+	g.botWait = gm.config.BotWait
+	g.reconnectGrace = gm.config.ReconnectGrace
+	g.newBot = gm.config.NewBot
+	g.newHintBot = gm.config.NewHintBot
+	g.newAnalyzer = gm.config.NewAnalyzer
+}
+
+// addGame adds the game to the games and starts it, the caller holds the
+// games mutex
+func (gm *GameManager) addGame(games map[string]*Game, g *Game) {
+	games[g.Name] = g
+	go g.Run()
 }
 
+// getAvailableGame returns a random game waiting for a player, a new game is
+// created when there is none
 func (gm *GameManager) getAvailableGame() *Game {
+	gm.gamesMutex.Lock()
+	defer gm.gamesMutex.Unlock()
+
 	for _, game := range gm.Games {
-		if game.SessionCount() == 1 {
+		if game.PlayerCount() == 1 && !game.Ended() {
 			return game
 		}
 	}
-	return nil
+
+	g := NewGame(gameWidth, gameHeight, randomData.SillyName(), gm.logger)
+	gm.configureGame(g)
+	gm.addGame(gm.Games, g)
+	return g
 }
 
 func (gm *GameManager) SessionCount() int {
 	sum := 0
-	for _, game := range gm.UserCreatedGames {
-		sum += game.SessionCount()
-	}
-	for _, game := range gm.Games {
-		sum += game.SessionCount()
+	for _, managed := range gm.allGames() {
+		sum += managed.game.SessionCount()
 	}
 	return sum
 }
 
 func (gm *GameManager) GameCount() int {
-	return len(gm.UserCreatedGames) + len(gm.Games)
+	gm.gamesMutex.RLock()
+	defer gm.gamesMutex.RUnlock()
+	return len(gm.UserCreatedGames) + len(gm.Games) + len(gm.PuzzleGames) + len(gm.DrillGames)
+}
+
+// newPuzzleGame returns a game of its own for the player to solve puzzles in
+func (gm *GameManager) newPuzzleGame(playerName string) (*Game, error) {
+	if gm.config.Puzzles == nil {
+		return nil, errors.New("puzzles are not available")
+	}
+	g, err := NewPuzzleGame(gameWidth, gameHeight, randomData.SillyName(), gm.config.Puzzles, playerName, gm.logger)
+	if err != nil {
+		return nil, err
+	}
+	gm.gamesMutex.Lock()
+	gm.addGame(gm.PuzzleGames, g)
+	gm.gamesMutex.Unlock()
+	return g, nil
+}
+
+// newDrillGame returns a game of its own for the player to play the drill
+// against the strongest bot
+func (gm *GameManager) newDrillGame(drillName string) (*Game, error) {
+	if gm.config.NewBot == nil {
+		return nil, errors.New("drills are not available")
+	}
+	g, err := NewDrillGame(gameWidth, gameHeight, randomData.SillyName(), drillName, gm.config.NewBot(maxBotLevel), gm.logger)
+	if err != nil {
+		return nil, err
+	}
+	gm.configureGame(g)
+	gm.gamesMutex.Lock()
+	gm.addGame(gm.DrillGames, g)
+	gm.gamesMutex.Unlock()
+	return g, nil
 }
 
 /*
@@ -87,30 +205,71 @@ Session
 - Player
 */
 
-func (gm *GameManager) generateUserCreatedGame() *Game {
-	g := NewUserCreatedGame(gameWidth, gameHeight, randomData.SillyName())
-	gm.UserCreatedGames[g.Name] = g
-	return g
+// generateUserCreatedGame creates the room under a random name when the room
+// is full, the caller holds the games mutex
+func (gm *GameManager) generateUserCreatedGame(options RoomOptions) (*Game, error) {
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, randomData.SillyName(), options, gm.logger)
+	if err != nil {
+		return nil, err
+	}
+	gm.configureGame(g)
+	gm.addGame(gm.UserCreatedGames, g)
+	return g, nil
 }
 
-func (gm *GameManager) getUserCreatedGame(gameName string) *Game {
-	var g *Game
+func (gm *GameManager) getUserCreatedGame(options RoomOptions) (*Game, error) {
+	gameName := options.Name
+
+	gm.gamesMutex.Lock()
+	defer gm.gamesMutex.Unlock()
 
 	// check if the UserGame already exists in the map
-	if _, ok := gm.UserCreatedGames[gameName]; ok {
-		if gm.UserCreatedGames[gameName].SessionCount() == 1 {
-			g = gm.UserCreatedGames[gameName]
-		} else {
-			g = gm.generateUserCreatedGame()
+	if existingGame, ok := gm.UserCreatedGames[gameName]; ok {
+		if existingGame.PlayerCount() == 1 && !existingGame.Ended() {
+			return existingGame, nil
+		} else if existingGame.PlayerCount() > 1 {
+			return gm.generateUserCreatedGame(options)
 		}
 	}
 
-	if g == nil {
-		// create the game in UserGames
-		g = NewUserCreatedGame(gameWidth, gameHeight, gameName)
-		gm.UserCreatedGames[gameName] = g
+	// create the game in UserGames
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, gameName, options, gm.logger)
+	if err != nil {
+		return nil, err
 	}
-	return g
+	gm.configureGame(g)
+	gm.addGame(gm.UserCreatedGames, g)
+	return g, nil
+}
+
+// roomOptions returns the options for the room from the room name and the
+// command the player gave when connecting, for example
+// ssh alice#room@server fen 8/8/8/8/8/8/4K3/4k2R w - - 0 1
+func (gm *GameManager) roomOptions(gameName string, command []string) (RoomOptions, error) {
+	options, err := ParseRoomOptions(gameName)
+	if err != nil {
+		return options, err
+	}
+
+	if len(command) == 0 {
+		return options, nil
+	}
+
+	switch command[0] {
+	case "fen":
+		if options.Chess960 {
+			return options, errors.New("a room can not be both chess960 and start from a fen")
+		}
+		if options.Odds != "" {
+			return options, errors.New("odds can only be given from the starting position")
+		}
+		if err := options.SetFEN(strings.Join(command[1:], " ")); err != nil {
+			return options, err
+		}
+	default:
+		return options, fmt.Errorf("unknown command: %s", command[0])
+	}
+	return options, nil
 }
 
 func (gm *GameManager) getPlayerAndGameName(username string) (string, string) {
@@ -123,33 +282,142 @@ func (gm *GameManager) getPlayerAndGameName(username string) (string, string) {
 	return username, ""
 }
 
-func (gm *GameManager) HandleNewChannel(c ssh.Channel, user string) {
+// HandleNewChannel adds the player to a game, the command is the command given
+// in an exec request and is empty for a shell. The bot command connects a bot
+// that is sent the game's events instead of the board, the puzzle command
+// starts a game of puzzles for the player and the drill command starts the
+// named drill. Commands such as games and version print their output and exit
+// without joining a game. The player's name comes from the permissions when
+// the player authenticated and the game is drawn to fit the terminal's size.
+// The player's session is returned, it is nil when the player did not join a
+// game
+func (gm *GameManager) HandleNewChannel(c ssh.Channel, user string, permissions *ssh.Permissions, command []string, size TerminalSize) *Session {
 
 	playerName, gameName := gm.getPlayerAndGameName(user)
+	if permissions != nil {
+		if name, ok := permissions.Extensions[auth.PlayerExtension]; ok {
+			playerName = name
+		}
+	}
+
+	if len(command) > 0 {
+		if _, ok := gm.commands[command[0]]; ok {
+			gm.runExecCommand(c, command)
+			return nil
+		}
+	}
 
+	// a guest reconnects with the token sent to them, the rest of the command
+	// is run as given
+	token := ""
+	if len(command) > 0 && command[0] == "reconnect" {
+		if len(command) < 2 {
+			exitWithError(c, errors.New("a reconnect token is needed"))
+			return nil
+		}
+		token, command = command[1], command[2:]
+	}
+
+	var protocol *botProtocol
+	if len(command) > 0 && command[0] == "bot" {
+		var err error
+		protocol, err = newBotProtocol(command[1:])
+		if err != nil {
+			exitWithError(c, err)
+			return nil
+		}
+		command = nil
+	}
+
+	fingerprint, guest := "", false
+	if permissions != nil {
+		fingerprint = permissions.Extensions[auth.FingerprintExtension]
+		guest = permissions.Extensions[auth.GuestExtension] == "true"
+	}
+
+	// a player or bot whose connection dropped goes back to the held seat,
+	// anyone can use a guest's name so guests need their token
 	var g *Game
-	if gameName != "" {
+	var player *Player
+	if token != "" {
+		g, player = gm.heldSeat(tokenIdentity(token))
+		if player == nil || len(command) > 0 {
+			exitWithError(c, errors.New("there is no game to reconnect to"))
+			return nil
+		}
+	} else if len(command) == 0 && !guest {
+		g, player = gm.heldSeat(playerIdentity(playerName, fingerprint))
+	}
+
+	// a game started from a position needs a room of its own
+	if gameName == "" && len(command) > 0 && command[0] != "puzzle" && command[0] != "drill" {
+		gameName = randomData.SillyName()
+	}
+
+	if len(command) > 0 && command[0] == "puzzle" {
+		var err error
+		g, err = gm.newPuzzleGame(playerName)
+		if err != nil {
+			gm.logger.Debug(fmt.Sprintf("error creating puzzle game: %v", err.Error()))
+			exitWithError(c, err)
+			return nil
+		}
+		gameName, command = "", nil
+	}
+
+	if len(command) > 0 && command[0] == "drill" {
+		var err error
+		g, err = gm.newDrillGame(strings.Join(command[1:], " "))
+		if err != nil {
+			gm.logger.Debug(fmt.Sprintf("error creating drill game: %v", err.Error()))
+			exitWithError(c, err)
+			return nil
+		}
+		gameName, command = "", nil
+	}
+
+	if g == nil && gameName != "" {
 		gm.logger.Debug(fmt.Sprintf("user game name: %s", gameName))
-		g = gm.getUserCreatedGame(gameName)
+		options, err := gm.roomOptions(gameName, command)
+		if err == nil {
+			g, err = gm.getUserCreatedGame(options)
+		}
+		if err != nil {
+			gm.logger.Debug(fmt.Sprintf("error creating room: %v", err.Error()))
+			exitWithError(c, err)
+			return nil
+		}
 	}
 
 	if g == nil {
 		g = gm.getAvailableGame()
 	}
 
-	if g == nil {
-		g = NewGame(gameWidth, gameHeight, randomData.SillyName(), gm.logger)
-		gm.Games[g.Name] = g
+	var session *Session
+	if player != nil {
+		session = resumeSession(c, player, gm.logger)
+		g.reattach(player, session)
+	} else {
+		session = NewSession(c, g.WorldWidth(), g.WorldHeight(), playerName, gm.logger)
+	}
+	session.protocol = protocol
+	session.Resize(size)
+	session.Fingerprint = fingerprint
+	session.Guest = guest
+	if guest && gm.config.ReconnectGrace > 0 {
+		session.Player.reconnectToken = newReconnectToken()
 	}
-	go g.Run()
-
-	session := NewSession(c, g.WorldWidth(), g.WorldHeight(), playerName, gm.logger)
 
 	g.AddSession(session)
 
 	gm.logger.Print(fmt.Sprintf("player connected: %v", playerName))
 	gm.logger.Print(fmt.Sprintf("Player joined. Current stats: %d users, %d games", gm.SessionCount(), gm.GameCount()))
 
+	if protocol != nil {
+		go gm.readBotCommands(c, g, session)
+		return session
+	}
+
 	go func() {
 		reader := bufio.NewReader(c)
 		for {
@@ -157,6 +425,7 @@ func (gm *GameManager) HandleNewChannel(c ssh.Channel, user string) {
 			gm.logger.Debug(fmt.Sprintf("r: %d", r))
 			if err != nil {
 				gm.logger.Debug(err.Error())
+				g.connectionDropped(session)
 				break
 			}
 
@@ -173,18 +442,88 @@ func (gm *GameManager) HandleNewChannel(c ssh.Channel, user string) {
 					session.Player.HandleRight()
 				case keyF:
 					session.Player.HandleAction()
+				case keyR:
+					session.Player.HandleResign()
+				case keyO:
+					session.Player.HandleOfferDraw()
+				case keyU:
+					session.Player.HandleTakeback()
+				case keyC:
+					session.Player.HandleClaimDraw()
+				case keyE:
+					session.Player.HandleReserve()
+				case keyI:
+					session.Player.HandleHint()
+				case keyQ:
+					session.Player.HandleQuit()
+				case keyY:
+					session.Player.HandleYes()
+				case keyN:
+					session.Player.HandleNo()
+				case key1, key2, key3, key4, key5:
+					session.Player.HandleBotLevel(int(r - key1 + 1))
 				case keyCtrlC:
-					if g.SessionCount() == 1 {
-						if g.userCreatedGame {
-							delete(gm.UserCreatedGames, g.Name)
-						} else {
-							delete(gm.Games, g.Name)
-						}
-					}
-
-					g.RemoveSession(session, "a test message")
+					gm.leave(g, session)
 				}
 			}
 		}
 	}()
+	return session
+}
+
+// leave removes the session from the game, the game is removed when it was
+// the last session
+func (gm *GameManager) leave(g *Game, session *Session) {
+	if g.SessionCount() == 1 {
+		gm.gamesMutex.Lock()
+		if g.userCreatedGame {
+			delete(gm.UserCreatedGames, g.Name)
+		} else if g.puzzle != nil {
+			delete(gm.PuzzleGames, g.Name)
+		} else if g.drill != nil {
+			delete(gm.DrillGames, g.Name)
+		} else {
+			delete(gm.Games, g.Name)
+		}
+		gm.gamesMutex.Unlock()
+	}
+
+	g.Leave(session)
+}
+
+// heldSeat returns the game in progress and the player whose seat is held for
+// the identity, nil when there is none
+func (gm *GameManager) heldSeat(identity string) (*Game, *Player) {
+	for _, managed := range gm.allGames() {
+		if managed.game.snapshot().status != "playing" {
+			continue
+		}
+		if player := managed.game.heldPlayer(identity); player != nil {
+			return managed.game, player
+		}
+	}
+	return nil, nil
+}
+
+// exitWithError writes the error and closes the channel with a failed exit
+// status
+func exitWithError(c ssh.Channel, err error) {
+	fmt.Fprintf(c, "%s\r\n", err.Error())
+	c.SendRequest("exit-status", false, ssh.Marshal(&struct {
+		Status uint32
+	}{exitFailure}))
+	c.Close()
+}
+
+// readBotCommands passes each line the bot sends to its player until the
+// bot's connection drops
+func (gm *GameManager) readBotCommands(c ssh.Channel, g *Game, session *Session) {
+	scanner := bufio.NewScanner(c)
+	for scanner.Scan() {
+		session.Player.HandleCommand(scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		gm.logger.Debug(err.Error())
+	}
+	g.connectionDropped(session)
 }
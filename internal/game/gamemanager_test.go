@@ -0,0 +1,212 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/auth"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+// testChannel is an ssh channel the test writes the player's keys to, reading
+// from it returns EOF when it is closed like a dropped connection
+type testChannel struct {
+	reader *io.PipeReader
+	writer *io.PipeWriter
+	mutex  sync.Mutex
+	output bytes.Buffer
+}
+
+func newTestChannel() *testChannel {
+	reader, writer := io.Pipe()
+	return &testChannel{reader: reader, writer: writer}
+}
+
+func (c *testChannel) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+func (c *testChannel) Write(p []byte) (int, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.output.Write(p)
+}
+
+func (c *testChannel) Close() error {
+	return c.writer.Close()
+}
+
+func (c *testChannel) CloseWrite() error {
+	return nil
+}
+
+func (c *testChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return true, nil
+}
+
+func (c *testChannel) Stderr() io.ReadWriter {
+	return &bytes.Buffer{}
+}
+
+// guestPermissions returns the permissions of a player who connected as a
+// guest
+func guestPermissions(name string) *ssh.Permissions {
+	return &ssh.Permissions{Extensions: map[string]string{auth.PlayerExtension: name, auth.GuestExtension: "true"}}
+}
+
+// hasStatus returns the condition that the game has the status the commands
+// show
+func hasStatus(g *Game, status string) func() bool {
+	return func() bool {
+		return g.snapshot().status == status
+	}
+}
+
+// waitFor checks the condition until it is true or a second has passed
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+	return condition()
+}
+
+func Test_HandleNewChannel_Should_Run_The_Clock_In_Real_Time_When_Two_Players_Join(t *testing.T) {
+	gm := newTestGameManager()
+	gm.HandleNewChannel(newTestChannel(), "alice#clock+5", nil, nil, TerminalSize{})
+	gm.HandleNewChannel(newTestChannel(), "bob#clock+5", nil, nil, TerminalSize{})
+	g := gm.UserCreatedGames["clock"]
+	// the clock starts running when the game loop starts the game
+	assert.True(t, waitFor(func() bool { return g.clock.Remaining(White) < 5*time.Minute }), "should start the game")
+
+	before := g.clock.Remaining(White)
+	time.Sleep(time.Second)
+	used := before - g.clock.Remaining(White)
+
+	assert.Equal(t, 2, g.SessionCount(), "should be equal")
+	assert.InDelta(t, float64(time.Second), float64(used), float64(200*time.Millisecond), "should take a second off the clock")
+}
+
+func Test_ReadBotCommands_Should_Leave_The_Game_When_The_Bots_Connection_Drops(t *testing.T) {
+	gm := newTestGameManager()
+	bot := newTestChannel()
+	mybot := gm.HandleNewChannel(bot, "mybot#room", nil, []string{"bot"}, TerminalSize{})
+	alice := gm.HandleNewChannel(newTestChannel(), "alice#room", nil, nil, TerminalSize{})
+	g := gm.UserCreatedGames["room"]
+	assert.True(t, waitFor(hasStatus(g, "playing")), "should start the game")
+
+	bot.Close()
+	assert.True(t, waitFor(hasStatus(g, "over")), "should end the game")
+	assert.True(t, waitFor(func() bool { return !g.hasSession(mybot) }), "should remove the bot's session")
+	snapshot := g.snapshot()
+	assert.Equal(t, winner(alice.Player.PlayerColor.modelColor()), snapshot.outcome, "should be equal")
+	assert.Equal(t, "resignation", snapshot.method, "should be equal")
+}
+
+func Test_ReadBotCommands_Should_Hold_The_Bots_Seat_When_The_Bots_Connection_Drops(t *testing.T) {
+	gm := newTestGameManager()
+	gm.config.ReconnectGrace = time.Minute
+	bot := newTestChannel()
+	mybot := gm.HandleNewChannel(bot, "mybot#room", nil, []string{"bot"}, TerminalSize{})
+	gm.HandleNewChannel(newTestChannel(), "alice#room", nil, nil, TerminalSize{})
+	g := gm.UserCreatedGames["room"]
+	assert.True(t, waitFor(hasStatus(g, "playing")), "should start the game")
+
+	bot.Close()
+	assert.True(t, waitFor(func() bool { return g.heldPlayer("name:mybot") != nil }), "should hold the bot's seat")
+	assert.Equal(t, "playing", g.snapshot().status, "should be equal")
+
+	resumed := gm.HandleNewChannel(newTestChannel(), "mybot#room", nil, []string{"bot"}, TerminalSize{})
+	assert.Equal(t, mybot.Player, resumed.Player, "should be equal")
+	assert.NotNil(t, resumed.protocol, "should not be nil")
+	assert.Equal(t, 0, len(g.heldPlayers()), "should be equal")
+}
+
+func Test_HandleNewChannel_Should_Reattach_A_Guest_Only_When_Given_The_Token(t *testing.T) {
+	gm := newTestGameManager()
+	gm.config.ReconnectGrace = time.Minute
+	guest := newTestChannel()
+	alice := gm.HandleNewChannel(guest, "alice#room", guestPermissions("alice"), nil, TerminalSize{})
+	gm.HandleNewChannel(newTestChannel(), "bob#room", nil, nil, TerminalSize{})
+	g := gm.UserCreatedGames["room"]
+	assert.True(t, waitFor(hasStatus(g, "playing")), "should start the game")
+	token := alice.Player.reconnectToken
+	assert.NotEqual(t, "", token, "should give the guest a token")
+
+	guest.Close()
+	assert.True(t, waitFor(func() bool { return g.heldPlayer(tokenIdentity(token)) != nil }), "should hold the guest's seat")
+
+	// anyone can play as a guest named alice so the name is not enough
+	other := gm.HandleNewChannel(newTestChannel(), "alice", guestPermissions("alice"), nil, TerminalSize{})
+	assert.NotEqual(t, alice.Player, other.Player, "should not be equal")
+	assert.Equal(t, 1, len(g.heldPlayers()), "should be equal")
+
+	resumed := gm.HandleNewChannel(newTestChannel(), "alice", guestPermissions("alice"), []string{"reconnect", token}, TerminalSize{})
+	assert.Equal(t, alice.Player, resumed.Player, "should be equal")
+	assert.NotEqual(t, token, resumed.Player.reconnectToken, "should give the guest a new token")
+	assert.Equal(t, 0, len(g.heldPlayers()), "should be equal")
+
+	// the token can only be used once
+	assert.Nil(t, gm.HandleNewChannel(newTestChannel(), "alice", guestPermissions("alice"), []string{"reconnect", token}, TerminalSize{}), "should be nil")
+}
+
+func Test_RunCommand_Should_List_The_Games_When_Players_Join_At_The_Same_Time(t *testing.T) {
+	gm := newTestGameManager()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 50; i++ {
+			gm.HandleNewChannel(newTestChannel(), fmt.Sprintf("player%d#room%d", i, i), nil, nil, TerminalSize{})
+		}
+	}()
+
+	for listing := true; listing; {
+		select {
+		case <-done:
+			listing = false
+		default:
+			status, _, _ := runTestCommand(gm, "games")
+			assert.Equal(t, exitSuccess, status, "should be equal")
+		}
+	}
+	assert.Equal(t, 50, gm.GameCount(), "should be equal")
+}
+
+func Test_RunCommand_Should_Print_The_PGN_When_The_Game_Is_Being_Played(t *testing.T) {
+	gm := newTestGameManager()
+	channels := map[string]*testChannel{"alice": newTestChannel(), "bob": newTestChannel()}
+	gm.HandleNewChannel(channels["alice"], "alice#room", nil, []string{"bot"}, TerminalSize{})
+	gm.HandleNewChannel(channels["bob"], "bob#room", nil, []string{"bot"}, TerminalSize{})
+	g := gm.UserCreatedGames["room"]
+	assert.True(t, waitFor(hasStatus(g, "playing")), "should start the game")
+	players := g.snapshot().players
+
+	// the bots play while the pgn is printed
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i, move := range []string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "a7a6"} {
+			io.WriteString(channels[players[i%2].Name].writer, move+"\n")
+			waitFor(func() bool { return g.snapshot().plies == i+1 })
+		}
+	}()
+
+	for playing := true; playing; {
+		select {
+		case <-done:
+			playing = false
+		default:
+			status, _, _ := runTestCommand(gm, "pgn", "room")
+			assert.Equal(t, exitSuccess, status, "should be equal")
+		}
+	}
+	_, stdout, _ := runTestCommand(gm, "pgn", "room")
+	assert.True(t, strings.HasSuffix(stdout, "1. e4 e5 2. Nf3 Nc6 3. Bb5 a6 *\n"), "should print the moves played")
+}
@@ -0,0 +1,117 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+// hint is the move suggested to a player, its squares are highlighted until
+// the position changes
+type hint struct {
+	fen  string
+	from Position
+	to   Position
+}
+
+// requestHint starts a short search for the player's best move, hints are off
+// in rated games
+func (g *Game) requestHint(p *Player) {
+	switch {
+	case g.options.Rated:
+		p.Message = "hints are off in rated games"
+		return
+	case g.puzzle != nil:
+		p.Message = "hints are off in puzzles"
+		return
+	case g.newHintBot == nil:
+		p.Message = "hints are not available"
+		return
+	case !p.IsActive:
+		p.Message = "hints are only given on your turn"
+		return
+	case g.hintPlayer != nil:
+		return
+	}
+
+	p.Message = "looking for a hint"
+	g.hintPlayer = p
+	bot := g.newHintBot()
+	fen := g.Model.Position().String()
+	go func() {
+		// the search gets its own copy of the position as the position caches
+		// its moves and is still used by the game
+		startingPosition, err := chess.FEN(fen)
+		if err != nil {
+			g.hintMoves <- botMove{fen: fen, err: err}
+			return
+		}
+		move, err := bot.Move(chess.NewGame(startingPosition).Position())
+		g.hintMoves <- botMove{fen: fen, move: move, err: err}
+	}()
+}
+
+// updateHint shows the hint once the search has finished, the hint is counted
+// against the player who asked for it
+func (g *Game) updateHint() {
+	var result botMove
+	select {
+	case result = <-g.hintMoves:
+	default:
+		return
+	}
+
+	p := g.hintPlayer
+	g.hintPlayer = nil
+	position := g.Model.Position()
+	if !g.InProgress() || result.fen != position.String() {
+		return
+	}
+
+	var move *chess.Move
+	if result.err == nil {
+		for _, m := range g.validMoves() {
+			if m.S1() == result.move.S1() && m.S2() == result.move.S2() && m.Promo() == result.move.Promo() {
+				move = m
+			}
+		}
+	}
+	if move == nil {
+		p.Message = "there is no hint for this position"
+		return
+	}
+
+	color := position.Turn()
+	g.hints[color]++
+	g.hint = &hint{
+		fen:  result.fen,
+		from: squareToPosition(move.S1()),
+		to:   squareToPosition(move.S2()),
+	}
+	p.Message = fmt.Sprintf("hint: %s", chess.AlgebraicNotation{}.Encode(position, move))
+	g.logger.Debug(fmt.Sprintf("%s was given hint %s", p.Name, move))
+}
+
+// setHintColors highlights the squares of the hint while the position has not
+// changed
+func (g *Game) setHintColors() {
+	if g.hint == nil || g.hint.fen != g.Model.Position().String() {
+		return
+	}
+	g.setSquareColor(g.hint.from, Yellow)
+	g.setSquareColor(g.hint.to, Yellow)
+}
+
+// hintTagPairs returns tags with the number of hints each player was given
+func (g *Game) hintTagPairs() []*chess.TagPair {
+	tagPairs := []*chess.TagPair{}
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if g.hints[color] > 0 {
+			tagPairs = append(tagPairs, &chess.TagPair{
+				Key:   fmt.Sprintf("%sHints", color.Name()),
+				Value: fmt.Sprint(g.hints[color]),
+			})
+		}
+	}
+	return tagPairs
+}
@@ -0,0 +1,65 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testHintBot returns the bot that suggests the moves as hints
+func testHintBot(moves ...string) func() Bot {
+	return func() Bot {
+		return &testBot{moves: moves}
+	}
+}
+
+func Test_RequestHint_Should_Highlight_The_Move_When_The_Search_Finishes(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.newHintBot = testHintBot("g1f3")
+
+	g.requestHint(white)
+	assert.Equal(t, "looking for a hint", white.Message, "should be equal")
+	updateUntil(g, func() bool { return g.hint != nil })
+
+	assert.Equal(t, "hint: Nf3", white.Message, "should be equal")
+	g.SetPositionColor(Position{0, 0}, Green)
+	assert.Equal(t, Yellow, g.boardColors[modelToPosition("g1")], "should be equal")
+	assert.Equal(t, Yellow, g.boardColors[modelToPosition("f3")], "should be equal")
+	assert.Equal(t, Green, g.boardColors[Position{0, 0}], "should be equal")
+	assert.True(t, strings.Contains(g.PGN(), "[WhiteHints \"1\"]"), "should count the hint")
+	assert.False(t, strings.Contains(g.PGN(), "BlackHints"), "should not count hints for black")
+
+	// the hint is no longer shown once a move is played
+	playMoves(g, white, black, "g1f3")
+	g.SetPositionColor(Position{0, 0}, Green)
+	assert.Equal(t, None, g.boardColors[modelToPosition("g1")], "should be equal")
+}
+
+func Test_RequestHint_Should_Not_Give_A_Hint_When_The_Game_Is_Rated(t *testing.T) {
+	g, white, _ := newStartedTestGame("alice", "bob")
+	g.newHintBot = testHintBot("g1f3")
+	g.options.Rated = true
+
+	g.requestHint(white)
+
+	assert.Equal(t, "hints are off in rated games", white.Message, "should be equal")
+	assert.Nil(t, g.hintPlayer, "should be nil")
+}
+
+func Test_RequestHint_Should_Not_Give_A_Hint_When_It_Is_Not_The_Players_Turn(t *testing.T) {
+	g, _, black := newStartedTestGame("alice", "bob")
+	g.newHintBot = testHintBot("g1f3")
+
+	g.requestHint(black)
+
+	assert.Equal(t, "hints are only given on your turn", black.Message, "should be equal")
+	assert.Equal(t, 0, g.hints[black.PlayerColor.modelColor()], "should be equal")
+}
+
+func Test_ParseRoomOptions_Should_Return_A_Rated_Room_When_Given_Rated(t *testing.T) {
+	options, err := ParseRoomOptions("room+rated+5+3")
+	assert.Nil(t, err, "should be nil")
+	assert.True(t, options.Rated, "should be rated")
+	assert.Equal(t, "300+3", options.TimeControl.PGNString(), "should be equal")
+}
@@ -2,6 +2,7 @@ package game
 
 import (
 	"fmt"
+	"sync"
 )
 
 type Hub struct {
@@ -9,6 +10,9 @@ type Hub struct {
 	Redraw     chan struct{}
 	Register   chan *Session
 	Unregister chan UnregisterMessage
+	// sessionsMutex guards the sessions, they are read outside of Run by the
+	// game and the commands that list the games
+	sessionsMutex sync.RWMutex
 }
 
 func NewHub() Hub {
@@ -29,17 +33,25 @@ func (h *Hub) Run(g *Game) {
 			}
 		case s := <-h.Register:
 			// Hide the cursor
-			fmt.Fprint(s, "\033[?25l")
+			if s.protocol == nil {
+				fmt.Fprint(s, "\033[?25l")
+			}
 
+			h.sessionsMutex.Lock()
 			h.Sessions[s] = struct{}{}
+			h.sessionsMutex.Unlock()
 		case s := <-h.Unregister:
 			if _, ok := h.Sessions[s.session]; ok {
 				fmt.Fprint(s.session, s.message)
 
 				// Unhide the cursor
-				fmt.Fprint(s.session, "\033[?25h")
+				if s.session.protocol == nil {
+					fmt.Fprint(s.session, "\033[?25h")
+				}
 
+				h.sessionsMutex.Lock()
 				delete(h.Sessions, s.session)
+				h.sessionsMutex.Unlock()
 				s.session.c.Close()
 			}
 		}
@@ -0,0 +1,43 @@
+package game
+
+import (
+	"github.com/notnil/chess"
+)
+
+// the squares a king has to reach to win king of the hill
+var hillSquares = []chess.Square{chess.D4, chess.E4, chess.D5, chess.E5}
+
+// KingOfTheHill is won by checkmate or by moving the king to one of the four
+// squares in the center of the board
+type KingOfTheHill struct{}
+
+func (KingOfTheHill) Name() string {
+	return "King of the Hill"
+}
+
+func (KingOfTheHill) Setup(g *Game) error {
+	g.Model.AddTagPair("Variant", "King of the Hill")
+	return nil
+}
+
+func (KingOfTheHill) ValidMoves(g *Game, moves []*chess.Move) []*chess.Move {
+	return moves
+}
+
+func (KingOfTheHill) Outcome(g *Game) (chess.Outcome, string) {
+	board := g.Model.Position().Board()
+	for _, square := range hillSquares {
+		if piece := board.Piece(square); piece.Type() == chess.King {
+			return winner(piece.Color()), "king in the center"
+		}
+	}
+	return modelOutcome(g)
+}
+
+func (KingOfTheHill) Status(g *Game) string {
+	return ""
+}
+
+func (KingOfTheHill) TakenPieces(g *Game, color chess.Color) []string {
+	return g.capturedPieces(color)
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// the standard screen is the world with a border around it
+	standardWidth  = gameWidth + 2
+	standardHeight = gameHeight + 2
+
+	// the compact screen has a character for each square of the board
+	compactWidth  = 24
+	compactHeight = 14
+
+	// panelWidth is the width of a panel beside the standard screen
+	panelWidth = 26
+
+	// emptySquare is drawn on the empty squares of the compact board
+	emptySquare = "·"
+)
+
+type layout int
+
+const (
+	standardLayout layout = iota
+	// compactLayout is for terminals smaller than the standard screen
+	compactLayout
+	// wideLayout adds the moves panel to the right of the standard screen
+	wideLayout
+	// widestLayout also adds the keys panel to the left
+	widestLayout
+	// tooSmallLayout is for terminals nothing fits in
+	tooSmallLayout
+)
+
+// the keys shown in the keys panel
+var keysPanel = []string{
+	"keys",
+	"",
+	"wasd/hjkl move the cursor",
+	"f selects and places",
+	"r resigns",
+	"o offers a draw",
+	"c claims a draw or win",
+	"u asks for a takeback",
+	"i shows a hint",
+	"e opens the reserve",
+	"y/n answer questions",
+	"ctrl-c leaves",
+}
+
+// layoutFor returns the layout that fits the terminal, the standard layout is
+// used when the size is not known
+func layoutFor(size TerminalSize) layout {
+	switch {
+	case size.Width == 0 || size.Height == 0:
+		return standardLayout
+	case size.Width < compactWidth || size.Height < compactHeight:
+		return tooSmallLayout
+	case size.Width < standardWidth || size.Height < standardHeight:
+		return compactLayout
+	case size.Width >= standardWidth+2*panelWidth:
+		return widestLayout
+	case size.Width >= standardWidth+panelWidth:
+		return wideLayout
+	}
+	return standardLayout
+}
+
+// fitString cuts the string off at the width
+func fitString(s string, width int) string {
+	if runes := []rune(s); len(runes) > width {
+		return string(runes[:width])
+	}
+	return s
+}
+
+// padString fills the string with spaces up to the width
+func padString(s string, width int) string {
+	s = fitString(s, width)
+	return s + strings.Repeat(" ", width-len([]rune(s)))
+}
+
+// tooSmallString returns the screen shown when the terminal is too small for
+// the game
+func tooSmallString(size TerminalSize) string {
+	lines := []string{
+		"terminal too small",
+		fmt.Sprintf("%dx%d, needs %dx%d", size.Width, size.Height, compactWidth, compactHeight),
+	}
+	if len(lines) > size.Height {
+		lines = lines[:size.Height]
+	}
+	for i := range lines {
+		lines[i] = fitString(lines[i], size.Width)
+	}
+	return strings.Join(lines, "\r\n")
+}
+
+// squareString returns the piece on the square of the compact board in the
+// color the square is highlighted in
+func (g *Game) squareString(p Position) string {
+	piece := g.pieceString(p)
+	if piece == " " {
+		piece = emptySquare
+	}
+
+	// a square is highlighted when all of its corners are
+	g.mutex.RLock()
+	c := g.boardColors[p]
+	for _, corner := range []Position{{p.x + 1, p.y}, {p.x, p.y + 1}, {p.x + 1, p.y + 1}} {
+		if g.boardColors[corner] != c {
+			c = None
+		}
+	}
+	g.mutex.RUnlock()
+	return colorString(c, piece)
+}
+
+// compactString returns the room with a character for each square of the
+// board for terminals smaller than the standard screen
+func (g *Game) compactString(s *Session, size TerminalSize) string {
+	lines := []string{fitString(g.headerString(s.Player), size.Width), "  a b c d e f g h"}
+	for y := 0; y < 8; y++ {
+		row := fmt.Sprint(8 - y)
+		for x := 0; x < 8; x++ {
+			row += " " + g.squareString(Position{x, y})
+		}
+		lines = append(lines, row)
+	}
+	lines = append(lines, "")
+
+	for player := range g.players() {
+		if player != s.Player {
+			lines = append(lines, fitString(g.playerString(player)+strings.Join(player.TakenPiecesList, ""), size.Width))
+		}
+	}
+	lines = append(lines, fitString(g.playerString(s.Player)+strings.Join(s.Player.TakenPiecesList, ""), size.Width))
+	lines = append(lines, fitString(g.pickerString(s.Player), size.Width))
+	return strings.Join(lines, "\r\n")
+}
+
+// movesPanel returns the lines of the moves panel, the last moves are shown
+// when they do not all fit in the height
+func (g *Game) movesPanel(height int) []string {
+	moves := []string{}
+	for i, san := range g.record {
+		number := g.moveNumberString(i)
+		if i > 0 && strings.HasSuffix(number, "...") {
+			moves[len(moves)-1] += " " + san
+			continue
+		}
+		moves = append(moves, number+" "+san)
+	}
+	if len(moves) > height-2 {
+		moves = moves[len(moves)-(height-2):]
+	}
+	return append([]string{"moves", ""}, moves...)
+}
+
+// panelString adds the panels of the layout beside the standard screen
+func (g *Game) panelString(room string, l layout) string {
+	lines := strings.Split(room, "\r\n")
+	moves := g.movesPanel(len(lines))
+	for i := range lines {
+		if l == widestLayout {
+			keys := ""
+			if i < len(keysPanel) {
+				keys = keysPanel[i]
+			}
+			lines[i] = padString(" "+keys, panelWidth) + lines[i]
+		}
+		if i < len(moves) {
+			lines[i] += fitString(" "+moves[i], panelWidth)
+		}
+	}
+	return strings.Join(lines, "\r\n")
+}
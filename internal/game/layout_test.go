@@ -0,0 +1,109 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stripColors removes the escape codes of the colors from the screen
+func stripColors(screen string) string {
+	for strings.Contains(screen, "\033[") {
+		start := strings.Index(screen, "\033[")
+		end := strings.Index(screen[start:], "m")
+		screen = screen[:start] + screen[start+end+1:]
+	}
+	return screen
+}
+
+func Test_LayoutFor_Should_Return_The_Layout_When_Given_The_Terminal_Size(t *testing.T) {
+	tests := []struct {
+		size     TerminalSize
+		expected layout
+	}{
+		{TerminalSize{}, standardLayout},
+		{TerminalSize{Width: 20, Height: 30}, tooSmallLayout},
+		{TerminalSize{Width: 80, Height: 10}, tooSmallLayout},
+		{TerminalSize{Width: 24, Height: 14}, compactLayout},
+		{TerminalSize{Width: 79, Height: 40}, compactLayout},
+		{TerminalSize{Width: 120, Height: 23}, compactLayout},
+		{TerminalSize{Width: 80, Height: 24}, standardLayout},
+		{TerminalSize{Width: 106, Height: 24}, wideLayout},
+		{TerminalSize{Width: 132, Height: 50}, widestLayout},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, layoutFor(test.size), "should be equal")
+	}
+}
+
+func Test_TooSmallString_Should_Fit_The_Terminal_When_Given_The_Size(t *testing.T) {
+	assert.Equal(t, "terminal too small\r\n20x10, needs 24x14", tooSmallString(TerminalSize{Width: 20, Height: 10}), "should be equal")
+	assert.Equal(t, "termina", tooSmallString(TerminalSize{Width: 7, Height: 1}), "should be equal")
+}
+
+func Test_CompactString_Should_Fit_The_Terminal_When_The_Terminal_Is_Small(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	playMoves(g, white, black, "e2e4", "d7d5", "e4d5")
+	s := &Session{Player: white}
+	size := TerminalSize{Width: compactWidth, Height: compactHeight}
+
+	lines := strings.Split(stripColors(g.compactString(s, size)), "\r\n")
+	assert.Equal(t, compactHeight, len(lines), "should be equal")
+	for _, line := range lines {
+		assert.True(t, utf8.RuneCountInString(line) <= compactWidth, "should fit the width")
+	}
+	assert.Equal(t, "  a b c d e f g h", lines[1], "should be equal")
+	assert.Equal(t, "5 · · · ♟ · · · ·", lines[5], "should be equal")
+	assert.Equal(t, " [ ♔bob ] ", lines[11], "should be equal")
+	assert.Equal(t, " ♚alice ♙", lines[12], "should be equal")
+}
+
+func Test_SquareString_Should_Color_The_Square_When_It_Is_Highlighted(t *testing.T) {
+	g, _, _ := newStartedTestGame("alice", "bob")
+	g.SetPositionColor(Position{4, 6}, Green)
+
+	assert.NotEqual(t, "♟", g.squareString(Position{4, 6}), "should be colored")
+	assert.Equal(t, "♟", g.squareString(Position{3, 6}), "should be equal")
+	assert.Equal(t, "♟", g.squareString(Position{5, 6}), "should be equal")
+	assert.Equal(t, emptySquare, g.squareString(Position{4, 4}), "should be equal")
+}
+
+func Test_MovesPanel_Should_Show_The_Last_Moves_When_They_Do_Not_Fit(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6", "f1b5")
+
+	assert.Equal(t, []string{"moves", "", "1. e4 e5", "2. Nf3 Nc6", "3. Bb5"}, g.movesPanel(24), "should be equal")
+	assert.Equal(t, []string{"moves", "", "2. Nf3 Nc6", "3. Bb5"}, g.movesPanel(4), "should be equal")
+
+	g, _, _ = newStartedTestGame("alice", "bob")
+	g.setupFEN("4k3/8/8/8/8/8/4P3/4K3 b - - 0 30")
+	g.record = []string{"Kd7", "e4"}
+	assert.Equal(t, []string{"moves", "", "30... Kd7", "31. e4"}, g.movesPanel(24), "should be equal")
+}
+
+func Test_PanelString_Should_Add_The_Panels_When_The_Terminal_Is_Wide(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	playMoves(g, white, black, "e2e4", "e7e5")
+	room := g.roomString(&Session{Player: white})
+
+	tests := []struct {
+		layout layout
+		left   int
+	}{
+		{wideLayout, 0},
+		{widestLayout, panelWidth},
+	}
+
+	for _, test := range tests {
+		lines := strings.Split(stripColors(g.panelString(room, test.layout)), "\r\n")
+		assert.Equal(t, standardHeight, len(lines), "should be equal")
+		for _, line := range lines {
+			assert.True(t, utf8.RuneCountInString(line) <= test.left+standardWidth+panelWidth, "should fit the width")
+		}
+		assert.Equal(t, " 1. e4 e5", string([]rune(lines[2])[test.left+standardWidth:]), "should be equal")
+		assert.Equal(t, test.layout == widestLayout, strings.HasPrefix(lines[0], " keys"), "should be equal")
+	}
+}
@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// timeOdds is the odds name of a room where the player who created it has
+// less time on their clock
+const timeOdds = "time"
+
+// materialOdds is a handicap where the player who gives it starts without a
+// piece
+type materialOdds struct {
+	title string
+	fen   string
+	// giver is the color of the player who gives the odds
+	giver chess.Color
+}
+
+// the piece given is removed from the starting position, in pawn and move the
+// player giving the odds plays black without the f7 pawn
+var materialOddsSetups = map[string]materialOdds{
+	"queen": {
+		title: "queen odds",
+		fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1",
+		giver: chess.White,
+	},
+	"rook": {
+		title: "rook odds",
+		fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1",
+		giver: chess.White,
+	},
+	"knight": {
+		title: "knight odds",
+		fen:   "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/R1BQKBNR w KQkq - 0 1",
+		giver: chess.White,
+	},
+	"pawn": {
+		title: "pawn and move",
+		fen:   "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		giver: chess.Black,
+	},
+}
+
+// setOdds sets the handicap from the value of the odds room option, a number
+// of minutes is time odds
+func (o *RoomOptions) setOdds(value string) error {
+	if _, ok := materialOddsSetups[value]; ok {
+		o.Odds = value
+		return nil
+	}
+
+	if minutesRegexp.MatchString(value) {
+		minutes, err := strconv.ParseFloat(value, 64)
+		if err == nil && minutes > 0 {
+			o.Odds = timeOdds
+			o.OddsTime = time.Duration(minutes * float64(time.Minute))
+			return nil
+		}
+	}
+
+	names := []string{}
+	for name := range materialOddsSetups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return fmt.Errorf("unknown odds: %s, choose one of %s or a number of minutes", value, strings.Join(names, ", "))
+}
+
+// setupOdds starts the game from the position of the material odds
+func (g *Game) setupOdds() error {
+	odds, ok := materialOddsSetups[g.options.Odds]
+	if !ok {
+		return nil
+	}
+	return g.setupFEN(odds.fen)
+}
+
+// oddsGiverColor returns the color of the player who created the room and gives
+// the odds, the color is random in time odds games
+func (g *Game) oddsGiverColor() ChessPiecesColor {
+	if odds, ok := materialOddsSetups[g.options.Odds]; ok {
+		return colorFromModel(odds.giver)
+	}
+	return ChessPiecesColor(g.random.Intn(2))
+}
+
+// setupTimeOdds gives the player who gives the odds less time and tags each
+// player's time control
+func (g *Game) setupTimeOdds(giver ChessPiecesColor) {
+	if g.options.Odds != timeOdds || g.clock == nil {
+		return
+	}
+	g.clock.SetRemaining(giver, g.options.OddsTime)
+
+	oddsTimeControl := *g.options.TimeControl
+	oddsTimeControl.Base = g.options.OddsTime
+	timeControls := map[ChessPiecesColor]TimeControl{
+		giver:     oddsTimeControl,
+		1 - giver: *g.options.TimeControl,
+	}
+	for _, color := range []ChessPiecesColor{White, Black} {
+		g.Model.AddTagPair(fmt.Sprintf("%sTimeControl", color.modelColor().Name()), timeControls[color].PGNString())
+	}
+}
+
+// oddsString returns the handicap shown next to the room name
+func (g *Game) oddsString() string {
+	if odds, ok := materialOddsSetups[g.options.Odds]; ok {
+		return odds.title
+	}
+	if g.options.Odds == timeOdds {
+		return fmt.Sprintf("time odds %s", strconv.FormatFloat(g.options.OddsTime.Minutes(), 'f', -1, 64))
+	}
+	return ""
+}
@@ -0,0 +1,101 @@
+package game
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestOddsGame(t *testing.T, room string) (*Game, *Player, *Player) {
+	options, err := ParseRoomOptions(room)
+	assert.Nil(t, err, "should be nil")
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, "test", options, blanklogger.NewBlankLogger())
+	assert.Nil(t, err, "should be nil")
+
+	creator, opponent := newTestPlayer(White), newTestPlayer(White)
+	opponent.CreatedAt = creator.CreatedAt.Add(time.Second)
+	addTestPlayers(g, creator, opponent)
+	g.startGame()
+	return g, creator, opponent
+}
+
+func Test_ParseRoomOptions_Should_Return_The_Odds_When_Given_Odds(t *testing.T) {
+	tests := []struct {
+		room     string
+		odds     string
+		oddsTime time.Duration
+		isErr    bool
+	}{
+		{"room+odds=queen", "queen", 0, false},
+		{"room+odds=Knight", "knight", 0, false},
+		{"room+5+3+odds=1", timeOdds, time.Minute, false},
+		{"room+odds=1.5+10+5", timeOdds, 90 * time.Second, false},
+		{"room+odds=bishop", "", 0, true},
+		{"room+odds=1", "", 0, true},
+		{"room+odds=0+5", "", 0, true},
+		{"room+chess960+odds=rook", "", 0, true},
+	}
+
+	for _, test := range tests {
+		options, err := ParseRoomOptions(test.room)
+		if test.isErr {
+			assert.NotNil(t, err, "should not be nil")
+		} else {
+			assert.Nil(t, err, "should be nil")
+			assert.Equal(t, test.odds, options.Odds, "should be equal")
+			assert.Equal(t, test.oddsTime, options.OddsTime, "should be equal")
+		}
+	}
+}
+
+func Test_StartGame_Should_Give_The_Creator_The_Odds_When_The_Room_Has_Material_Odds(t *testing.T) {
+	tests := []struct {
+		room  string
+		fen   string
+		color ChessPiecesColor
+	}{
+		{"room+odds=queen", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNB1KBNR w KQkq - 0 1", White},
+		{"room+odds=rook", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1", White},
+		{"room+odds=pawn", "rnbqkbnr/ppppp1pp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", Black},
+	}
+
+	for _, test := range tests {
+		g, creator, opponent := newTestOddsGame(t, test.room)
+
+		assert.Equal(t, test.fen, g.Model.Position().String(), "should be equal")
+		assert.Contains(t, g.PGN(), "[SetUp \"1\"]", "should contain the SetUp tag")
+		assert.Contains(t, g.PGN(), "[FEN \""+test.fen+"\"]", "should contain the FEN")
+		assert.Equal(t, test.color, creator.PlayerColor, "should be equal")
+		assert.Equal(t, 1-test.color, opponent.PlayerColor, "should be equal")
+		assert.Equal(t, test.color == White, creator.IsActive, "should be equal")
+	}
+}
+
+func Test_StartGame_Should_Give_The_Creator_Less_Time_When_The_Room_Has_Time_Odds(t *testing.T) {
+	g, creator, opponent := newTestOddsGame(t, "room+5+3+odds=1")
+
+	assert.Equal(t, time.Minute, g.clock.Remaining(creator.PlayerColor), "should be equal")
+	assert.Equal(t, 5*time.Minute, g.clock.Remaining(opponent.PlayerColor), "should be equal")
+	assert.Contains(t, g.PGN(), "["+creator.PlayerColor.modelColor().Name()+"TimeControl \"60+3\"]", "should tag the creator's time control")
+	assert.Contains(t, g.PGN(), "["+opponent.PlayerColor.modelColor().Name()+"TimeControl \"300+3\"]", "should tag the opponent's time control")
+	assert.Equal(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", g.Model.Position().String(), "should be equal")
+}
+
+func Test_OddsGiverColor_Should_Pick_The_Color_From_The_Games_Random_Source_When_The_Room_Has_Time_Odds(t *testing.T) {
+	colors := func(seed int64) []ChessPiecesColor {
+		g := newTestRoomGame(t, RoomOptions{Odds: timeOdds})
+		g.random = rand.New(rand.NewSource(seed))
+		colors := []ChessPiecesColor{}
+		for i := 0; i < 10; i++ {
+			colors = append(colors, g.oddsGiverColor())
+		}
+		return colors
+	}
+
+	assert.Equal(t, colors(1), colors(1), "should be equal")
+	assert.Contains(t, colors(1), White, "should give white the odds")
+	assert.Contains(t, colors(1), Black, "should give black the odds")
+}
@@ -0,0 +1,61 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/notnil/chess"
+	"github.com/notnil/chess/opening"
+)
+
+var (
+	// the eco book takes a while to build so it is built once when it is
+	// first needed and shared by every game
+	ecoBook     *opening.BookECO
+	ecoBookOnce sync.Once
+)
+
+func bookECO() *opening.BookECO {
+	ecoBookOnce.Do(func() {
+		ecoBook = opening.NewBookECO()
+	})
+	return ecoBook
+}
+
+// updateOpening finds the deepest opening in the eco book the game's moves
+// have followed after a move is made or taken back, games that do not start
+// from the starting position have no opening
+func (g *Game) updateOpening() {
+	g.opening = nil
+	first := g.models()[0]
+	if first.Positions()[0].String() == chess.StartingPosition().String() {
+		g.opening = bookECO().Find(first.Moves())
+	}
+}
+
+// openingName returns the name of the opening without the eco code some names
+// in the book end with
+func openingName(o *opening.Opening) string {
+	return strings.TrimSuffix(o.Title(), "; "+o.Code())
+}
+
+// openingString returns the eco code and the name of the opening shown in the
+// header, for example C60 Ruy Lopez; Spanish Opening
+func (g *Game) openingString() string {
+	o := g.opening
+	if o == nil {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", o.Code(), openingName(o))
+}
+
+// addOpeningTags adds the opening to the tags of the finished game
+func (g *Game) addOpeningTags() {
+	o := g.opening
+	if o == nil {
+		return
+	}
+	g.Model.AddTagPair("ECO", o.Code())
+	g.Model.AddTagPair("Opening", openingName(o))
+}
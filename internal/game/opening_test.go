@@ -0,0 +1,61 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_OpeningString_Should_Return_The_Deepest_Opening_When_The_Moves_Are_In_The_Book(t *testing.T) {
+	tests := []struct {
+		moves    []string
+		expected string
+	}{
+		{[]string{}, ""},
+		{[]string{"e2e4", "e7e5"}, "C20 King Pawn Game"},
+		{[]string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5"}, "C60 Ruy Lopez; Spanish Opening"},
+		{[]string{"e2e4", "e7e5", "g1f3", "b8c6", "f1b5", "h7h6", "h2h3"}, "C60 Ruy Lopez; Spanish Opening"},
+	}
+
+	for _, test := range tests {
+		g := newTestGame()
+		playMoves(g, newTestPlayer(White), newTestPlayer(Black), test.moves...)
+		assert.Equal(t, test.expected, g.openingString(), "should be equal")
+	}
+}
+
+func Test_OpeningString_Should_Follow_The_Game_When_A_Move_Is_Taken_Back(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6", "f1b5")
+	assert.Equal(t, "C60 Ruy Lopez; Spanish Opening", g.openingString(), "should be equal")
+
+	assert.Nil(t, g.undoMove(), "should be nil")
+	assert.NotEqual(t, "C60 Ruy Lopez; Spanish Opening", g.openingString(), "should not be equal")
+}
+
+func Test_OpeningString_Should_Be_Empty_When_The_Game_Starts_From_A_Position(t *testing.T) {
+	g := newTestGame()
+	assert.Nil(t, g.setupFEN("rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1"), "should be nil")
+
+	playMoves(g, newTestPlayer(White), newTestPlayer(Black), "e7e5")
+
+	assert.Equal(t, "", g.openingString(), "should be equal")
+}
+
+func Test_EndGame_Should_Add_The_Opening_Tags_When_The_Game_Ends(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	go func() {
+		for range g.hub.Unregister {
+		}
+	}()
+	playMoves(g, white, black, "e2e4", "e7e5")
+
+	g.endGame(chess.WhiteWon, "resignation")
+
+	assert.True(t, strings.Contains(g.PGN(), "[ECO \"C20\"]"), "should tag the eco code")
+	assert.True(t, strings.Contains(g.PGN(), "[Opening \"King Pawn Game\"]"), "should tag the opening")
+}
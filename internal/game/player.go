@@ -14,6 +14,9 @@ type PlayerState int
 const (
 	SelectingPiece PlayerState = iota
 	PlacingPiece
+	PromotingPiece
+	SelectingReserve
+	DroppingPiece
 )
 
 type KeyState int
@@ -24,9 +27,22 @@ const (
 	KeyLeft
 	KeyRight
 	KeyAction
+	KeyResign
+	KeyOfferDraw
+	KeyTakeback
+	KeyClaimDraw
+	KeyReserve
+	KeyBotLevel
+	KeyHint
+	KeyQuit
+	KeyYes
+	KeyNo
 	KeyNone
 )
 
+// the pieces a pawn can be promoted to in the order they are shown in the picker
+var promotionPieces = []chess.PieceType{chess.Queen, chess.Rook, chess.Bishop, chess.Knight}
+
 type Player struct {
 	s                     *Session
 	Name                  string
@@ -36,9 +52,22 @@ type Player struct {
 	PlayerColor           ChessPiecesColor
 	PlayerState           PlayerState
 	SelectedPiecePosition *Position
+	PromotionIndex        int
+	ReserveIndex          int
+	DropPieceType         chess.PieceType
 	currentKeyState       KeyState
 	previousKeyState      KeyState
 	TakenPiecesList       []string
+	Prompt                PromptType
+	Message               string
+	AnalysisOffset        int
+	botLevel              int
+	botOffered            bool
+	bot                   Bot
+	botMoves              chan botMove
+	thinking              bool
+	commands              chan string
+	reconnectToken        string
 	logger                logger.Logger
 }
 
@@ -54,9 +83,15 @@ func NewPlayer(s *Session, worldWidth, worldHeight int, playerName string, logge
 		PlayerColor:           White,
 		PlayerState:           SelectingPiece,
 		SelectedPiecePosition: &Position{-1, -1},
+		PromotionIndex:        0,
+		ReserveIndex:          0,
+		DropPieceType:         chess.NoPieceType,
 		currentKeyState:       KeyNone,
 		previousKeyState:      KeyNone,
 		TakenPiecesList:       []string{},
+		Prompt:                NoPrompt,
+		Message:               "",
+		commands:              make(chan string, botCommandBuffer),
 		logger:                logger,
 	}
 
@@ -67,10 +102,19 @@ func NewPlayer(s *Session, worldWidth, worldHeight int, playerName string, logge
 func (p *Player) SetIsActive(b bool) {
 	p.IsActive = b
 	if b {
-		p.PlayerColor = Black
+		p.SetColor(White)
+	} else {
+		p.SetColor(Black)
+	}
+}
+
+// SetColor gives the player the color's pieces and puts the cursor on the
+// player's side of the board
+func (p *Player) SetColor(color ChessPiecesColor) {
+	p.PlayerColor = color
+	if color == White {
 		p.BoardPosition = &Position{0, 7}
 	} else {
-		p.PlayerColor = White
 		p.BoardPosition = &Position{0, 0}
 	}
 }
@@ -108,6 +152,16 @@ func (p *Player) getVaildPositionsForSelectedPiece(validMoves []*chess.Move) []P
 	return positions
 }
 
+// validPositions returns the positions the selected piece can be placed on
+// including the positions a king is placed on to castle in chess960
+func (p *Player) validPositions(g *Game) []Position {
+	positions := p.getVaildPositionsForSelectedPiece(g.validMoves())
+	for position := range g.castlingTargets(p.PlayerColor.modelColor(), *p.SelectedPiecePosition) {
+		positions = append(positions, position)
+	}
+	return positions
+}
+
 func (p *Player) HandleUp() {
 	p.currentKeyState = KeyUp
 }
@@ -128,42 +182,249 @@ func (p *Player) HandleAction() {
 	p.currentKeyState = KeyAction
 }
 
-func (p *Player) canTakePiece(pieceToTake string) bool {
-	var canTakePiece bool = false
-	switch p.PlayerColor {
-	case White:
-		if pieceToTake == blackKing || pieceToTake == blackQueen ||
-			pieceToTake == blackRook || pieceToTake == blackBishop ||
-			pieceToTake == blackKnight || pieceToTake == blackPawn {
-			canTakePiece = true
+func (p *Player) HandleResign() {
+	p.currentKeyState = KeyResign
+}
+
+func (p *Player) HandleOfferDraw() {
+	p.currentKeyState = KeyOfferDraw
+}
+
+func (p *Player) HandleTakeback() {
+	p.currentKeyState = KeyTakeback
+}
+
+func (p *Player) HandleClaimDraw() {
+	p.currentKeyState = KeyClaimDraw
+}
+
+func (p *Player) HandleReserve() {
+	p.currentKeyState = KeyReserve
+}
+
+func (p *Player) HandleQuit() {
+	p.currentKeyState = KeyQuit
+}
+
+func (p *Player) HandleHint() {
+	p.currentKeyState = KeyHint
+}
+
+// HandleBotLevel chooses the level of the bot to play when a bot game is
+// offered
+func (p *Player) HandleBotLevel(level int) {
+	p.botLevel = level
+	p.currentKeyState = KeyBotLevel
+}
+
+func (p *Player) HandleYes() {
+	p.currentKeyState = KeyYes
+}
+
+func (p *Player) HandleNo() {
+	p.currentKeyState = KeyNo
+}
+
+func (p *Player) canTakePiece(pieceToTake chess.Piece) bool {
+	return pieceToTake != chess.NoPiece && pieceToTake.Color() != p.PlayerColor.modelColor()
+}
+
+func (p *Player) canMovePiece(pieceToMove chess.Piece) bool {
+	return pieceToMove != chess.NoPiece && pieceToMove.Color() == p.PlayerColor.modelColor()
+}
+
+func (p *Player) isPromotionMove(validMoves []*chess.Move) bool {
+	moveString := p.SelectedPiecePosition.positionToModel() + p.BoardPosition.positionToModel()
+	for _, move := range validMoves {
+		if move.String()[0:4] == moveString && move.Promo() != chess.NoPieceType {
+			return true
 		}
-	case Black:
-		if pieceToTake == whiteKing || pieceToTake == whiteQueen ||
-			pieceToTake == whiteRook || pieceToTake == whiteBishop ||
-			pieceToTake == whiteKnight || pieceToTake == whitePawn {
-			canTakePiece = true
+	}
+	return false
+}
+
+func (p *Player) selectPromotionPiece(delta int) {
+	p.PromotionIndex = mathutil.Clamp(p.PromotionIndex+delta, 0, len(promotionPieces)-1)
+	p.logger.Debug(fmt.Sprintf("promotion piece: %s", promotionPieces[p.PromotionIndex]))
+}
+
+func (p *Player) selectReservePiece(g *Game, delta int) {
+	reserve := g.reservePieceTypes(p.PlayerColor.modelColor())
+	p.ReserveIndex = mathutil.Clamp(p.ReserveIndex+delta, 0, len(reserve)-1)
+	p.logger.Debug(fmt.Sprintf("reserve piece: %s", reserve[p.ReserveIndex]))
+}
+
+// toggleReserve opens the reserve to choose a piece to drop or puts the piece
+// being dropped back in the reserve
+func (p *Player) toggleReserve(g *Game) {
+	switch p.PlayerState {
+	case SelectingPiece:
+		if !g.crazyhouse() {
+			return
+		}
+		if len(g.reservePieceTypes(p.PlayerColor.modelColor())) == 0 {
+			p.Message = "there are no pieces in your reserve"
+			return
 		}
+		p.ReserveIndex = 0
+		p.PlayerState = SelectingReserve
+		p.logger.Debug("in selecting reserve state")
+	case SelectingReserve, DroppingPiece:
+		p.DropPieceType = chess.NoPieceType
+		p.PlayerState = SelectingPiece
+		p.logger.Debug("closing the reserve - in selecting piece state")
+	}
+}
+
+func (p *Player) dropPiece(g *Game) {
+	square := p.BoardPosition.positionToSquare()
+	p.logger.Debug(fmt.Sprintf("dropping %s on %s", p.DropPieceType, square))
+
+	if err := g.dropPiece(p.PlayerColor.modelColor(), p.DropPieceType, square); err != nil {
+		p.logger.Debug(fmt.Sprintf("error dropping piece: %v", err.Error()))
+		return
+	}
+	p.DropPieceType = chess.NoPieceType
+	p.PlayerState = SelectingPiece
+
+	g.moveMade()
+}
+
+func (p *Player) movePiece(g *Game, promotionPiece chess.PieceType) {
+	p.logger.Debug(fmt.Sprintf("selected piece x: %d y: %d", p.SelectedPiecePosition.x, p.SelectedPiecePosition.y))
+
+	p.logger.Debug(fmt.Sprintf("moving piece x: %d y: %d to x: %d y: %d - in selecting piece state",
+		p.SelectedPiecePosition.x,
+		p.SelectedPiecePosition.y,
+		p.BoardPosition.x,
+		p.BoardPosition.y))
+
+	p.PlayerState = SelectingPiece
+
+	//  move pieces in the model
+	selectedPieceModel := p.SelectedPiecePosition.positionToModel()
+	boardPositionModel := p.BoardPosition.positionToModel()
+	moveStr := selectedPieceModel + boardPositionModel + promotionPiece.String()
+	p.logger.Debug(fmt.Sprintf("move string: " + moveStr))
+	move, err := chess.UCINotation{}.Decode(g.Model.Position(), moveStr)
+	if err != nil {
+		p.logger.Debug(fmt.Sprintf("error decoding move: %v", err.Error()))
+		return
+	}
+
+	pieceToTake := g.capturedPiece(move)
+	if err := g.makeMove(move); err != nil {
+		p.logger.Debug(fmt.Sprintf("error moving piece: %v", err.Error()))
+		return
+	}
+
+	if p.canTakePiece(pieceToTake) {
+		p.logger.Debug(fmt.Sprintf("taking piece: %s", modelPieceString(pieceToTake)))
 	}
-	return canTakePiece
+
+	p.logger.Debug(g.Model.Position().Board().Draw())
+
+	g.moveMade()
+}
+
+func (p *Player) castle(g *Game, rook chess.Square) {
+	p.PlayerState = SelectingPiece
+	p.logger.Debug(fmt.Sprintf("castling with the rook on %s", rook))
+
+	if err := g.castle(p.PlayerColor.modelColor(), rook); err != nil {
+		p.logger.Debug(fmt.Sprintf("error castling: %v", err.Error()))
+		return
+	}
+
+	g.moveMade()
+}
+
+func (p *Player) offerDraw(g *Game) {
+	opponent := g.opponent(p)
+	if opponent == nil {
+		return
+	}
+	if opponent.Prompt != NoPrompt {
+		p.Message = "opponent is answering another question"
+		return
+	}
+	opponent.Prompt = DrawOfferPrompt
+	p.Message = "draw offer sent"
+	p.logger.Debug(fmt.Sprintf("%s offered a draw", p.Name))
+}
+
+func (p *Player) requestTakeback(g *Game) {
+	if !g.userCreatedGame {
+		p.Message = "takebacks are only allowed in named rooms"
+		return
+	}
+	if len(g.record) == 0 {
+		p.Message = "there are no moves to take back"
+		return
+	}
+
+	opponent := g.opponent(p)
+	if opponent == nil {
+		return
+	}
+	if opponent.Prompt != NoPrompt {
+		p.Message = "opponent is answering another question"
+		return
+	}
+	opponent.Prompt = TakebackPrompt
+	p.Message = "takeback request sent"
+	p.logger.Debug(fmt.Sprintf("%s requested a takeback", p.Name))
+}
+
+func (p *Player) claimDraw(g *Game) {
+	claimableDraws := g.claimableDraws()
+	if len(claimableDraws) == 0 {
+		p.Message = "there is no draw to claim"
+		return
+	}
+
+	p.logger.Debug(fmt.Sprintf("%s claimed a draw by %s", p.Name, methodString(claimableDraws[0])))
+	if err := g.Model.Draw(claimableDraws[0]); err != nil {
+		p.logger.Debug(fmt.Sprintf("error drawing game: %v", err.Error()))
+		return
+	}
+	g.endGame(chess.Draw, methodString(claimableDraws[0]))
 }
 
-func (p *Player) canMovePiece(pieceToMove string) bool {
-	var canMovePiece bool = false
-	switch p.PlayerColor {
-	case Black:
-		if pieceToMove == blackKing || pieceToMove == blackQueen ||
-			pieceToMove == blackRook || pieceToMove == blackBishop ||
-			pieceToMove == blackKnight || pieceToMove == blackPawn {
-			canMovePiece = true
+func (p *Player) answerPrompt(g *Game, accepted bool) {
+	prompt := p.Prompt
+	p.Prompt = NoPrompt
+
+	switch prompt {
+	case ResignPrompt:
+		if accepted {
+			g.Resign(p)
+		}
+	case DrawOfferPrompt:
+		if accepted {
+			if err := g.Model.Draw(chess.DrawOffer); err != nil {
+				p.logger.Debug(fmt.Sprintf("error drawing game: %v", err.Error()))
+			}
+			g.CheckGameState()
+		} else if opponent := g.opponent(p); opponent != nil {
+			opponent.Message = "draw offer declined"
 		}
-	case White:
-		if pieceToMove == whiteKing || pieceToMove == whiteQueen ||
-			pieceToMove == whiteRook || pieceToMove == whiteBishop ||
-			pieceToMove == whiteKnight || pieceToMove == whitePawn {
-			canMovePiece = true
+	case TakebackPrompt:
+		if opponent := g.opponent(p); opponent != nil {
+			if !accepted {
+				opponent.Message = "takeback declined"
+			} else if err := g.Takeback(opponent); err != nil {
+				p.logger.Debug(fmt.Sprintf("error taking back move: %v", err.Error()))
+				opponent.Message = err.Error()
+			} else {
+				opponent.Message = "takeback accepted"
+			}
+		}
+	case BotPrompt:
+		if accepted {
+			g.addBot(defaultBotLevel)
 		}
 	}
-	return canMovePiece
 }
 
 func (p *Player) Update(g *Game, delta float64) {
@@ -171,30 +432,56 @@ func (p *Player) Update(g *Game, delta float64) {
 		p.currentKeyState = KeyNone
 	}
 
+	if p.currentKeyState != KeyNone {
+		p.Message = ""
+	}
+
+	if g.showAnalysis {
+		p.updateAnalysisScreen(g)
+		p.previousKeyState = p.currentKeyState
+		return
+	}
+
 	switch p.currentKeyState {
 	case KeyUp:
-		if p.IsActive {
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			p.selectPromotionPiece(-1)
+		} else if p.IsActive && p.PlayerState == SelectingReserve {
+			p.selectReservePiece(g, -1)
+		} else if p.IsActive {
 			p.BoardPosition.y--
 			p.BoardPosition.x, p.BoardPosition.y = mathutil.Clamp(p.BoardPosition.x, 0, 7), mathutil.Clamp(p.BoardPosition.y, 0, 7)
 			p.logger.Debug(fmt.Sprintf("x: %d y: %d", p.BoardPosition.x, p.BoardPosition.y))
 		}
 
 	case KeyDown:
-		if p.IsActive {
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			p.selectPromotionPiece(1)
+		} else if p.IsActive && p.PlayerState == SelectingReserve {
+			p.selectReservePiece(g, 1)
+		} else if p.IsActive {
 			p.BoardPosition.y++
 			p.BoardPosition.x, p.BoardPosition.y = mathutil.Clamp(p.BoardPosition.x, 0, 7), mathutil.Clamp(p.BoardPosition.y, 0, 7)
 			p.logger.Debug(fmt.Sprintf("x: %d y: %d", p.BoardPosition.x, p.BoardPosition.y))
 		}
 
 	case KeyRight:
-		if p.IsActive {
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			p.selectPromotionPiece(1)
+		} else if p.IsActive && p.PlayerState == SelectingReserve {
+			p.selectReservePiece(g, 1)
+		} else if p.IsActive {
 			p.BoardPosition.x++
 			p.BoardPosition.x, p.BoardPosition.y = mathutil.Clamp(p.BoardPosition.x, 0, 7), mathutil.Clamp(p.BoardPosition.y, 0, 7)
 			p.logger.Debug(fmt.Sprintf("x: %d y: %d", p.BoardPosition.x, p.BoardPosition.y))
 		}
 
 	case KeyLeft:
-		if p.IsActive {
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			p.selectPromotionPiece(-1)
+		} else if p.IsActive && p.PlayerState == SelectingReserve {
+			p.selectReservePiece(g, -1)
+		} else if p.IsActive {
 			p.BoardPosition.x--
 			p.BoardPosition.x, p.BoardPosition.y = mathutil.Clamp(p.BoardPosition.x, 0, 7), mathutil.Clamp(p.BoardPosition.y, 0, 7)
 			p.logger.Debug(fmt.Sprintf("x: %d y: %d", p.BoardPosition.x, p.BoardPosition.y))
@@ -202,22 +489,33 @@ func (p *Player) Update(g *Game, delta float64) {
 
 	case KeyAction:
 
-		if p.IsActive && p.PlayerState == SelectingPiece {
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			promotionPiece := promotionPieces[p.PromotionIndex]
+			p.logger.Debug(fmt.Sprintf("promoting piece to: %s", promotionPiece))
+			p.movePiece(g, promotionPiece)
+
+		} else if p.IsActive && p.PlayerState == SelectingReserve {
+			p.DropPieceType = g.reservePieceTypes(p.PlayerColor.modelColor())[p.ReserveIndex]
+			p.PlayerState = DroppingPiece
+			p.logger.Debug(fmt.Sprintf("reserve piece selected: %s - in dropping piece state", p.DropPieceType))
+
+		} else if p.IsActive && p.PlayerState == DroppingPiece {
+			p.dropPiece(g)
+
+		} else if p.IsActive && p.PlayerState == SelectingPiece {
 			p.SelectedPiecePosition.x, p.SelectedPiecePosition.y = p.BoardPosition.x, p.BoardPosition.y
+			pieceToMove := g.pieceAt(*p.SelectedPiecePosition)
 			p.logger.Debug(fmt.Sprintf("selected piece: %v  x: %d y: %d",
-				g.board[p.SelectedPiecePosition.x][p.SelectedPiecePosition.y],
+				modelPieceString(pieceToMove),
 				p.SelectedPiecePosition.x,
 				p.SelectedPiecePosition.y))
 
-			// FIXME: check that a player can select the position on the board
-			pieceToMove := g.board[p.SelectedPiecePosition.x][p.SelectedPiecePosition.y]
 			if p.canMovePiece(pieceToMove) {
 				p.PlayerState = PlacingPiece
 				p.logger.Debug("piece selected - in placing piece state")
 
 				// display the valid moves
-				validMoves := g.Model.ValidMoves()
-				validPositions := p.getVaildPositionsForSelectedPiece(validMoves)
+				validPositions := p.validPositions(g)
 				p.logger.Debug(fmt.Sprintf("valid positions: %v", validPositions))
 			}
 
@@ -230,54 +528,95 @@ func (p *Player) Update(g *Game, delta float64) {
 			p.logger.Debug("putting piece back - in selecting piece state")
 
 		} else if p.IsActive && p.PlayerState == PlacingPiece {
-			validMoves := g.Model.ValidMoves()
-			validPositions := p.getVaildPositionsForSelectedPiece(validMoves)
-			positionIsValid := p.positionInList(validPositions)
-			if positionIsValid {
-
-				p.logger.Debug(fmt.Sprintf("selected piece x: %d y: %d", p.SelectedPiecePosition.x, p.SelectedPiecePosition.y))
-
-				p.logger.Debug(fmt.Sprintf("moving piece x: %d y: %d to x: %d y: %d - in selecting piece state",
-					p.SelectedPiecePosition.x,
-					p.SelectedPiecePosition.y,
-					p.BoardPosition.x,
-					p.BoardPosition.y))
-
-				pieceToTake := g.board[p.BoardPosition.x][p.BoardPosition.y]
-				if p.canTakePiece(pieceToTake) {
-					p.logger.Debug(fmt.Sprintf("taking piece: %s", pieceToTake))
-					p.TakenPiecesList = append(p.TakenPiecesList, pieceToTake)
-					p.logger.Debug(fmt.Sprintf("taken list: %v", p.TakenPiecesList))
+			validMoves := g.validMoves()
+			castlingTargets := g.castlingTargets(p.PlayerColor.modelColor(), *p.SelectedPiecePosition)
+			positionIsValid := p.positionInList(p.getVaildPositionsForSelectedPiece(validMoves))
+			if rook, ok := castlingTargets[*p.BoardPosition]; ok {
+				p.castle(g, rook)
+			} else if positionIsValid {
+				if p.isPromotionMove(validMoves) {
+					p.PromotionIndex = 0
+					p.PlayerState = PromotingPiece
+					p.logger.Debug("pawn reached the last rank - in promoting piece state")
+				} else {
+					p.movePiece(g, chess.NoPieceType)
 				}
+			}
+		}
 
-				selectedPiece := g.board[p.SelectedPiecePosition.x][p.SelectedPiecePosition.y]
-				g.board[p.BoardPosition.x][p.BoardPosition.y] = selectedPiece
-				g.board[p.SelectedPiecePosition.x][p.SelectedPiecePosition.y] = " "
-				p.PlayerState = SelectingPiece
+	case KeyQuit:
+		if p.IsActive && p.PlayerState == PromotingPiece {
+			p.SelectedPiecePosition = &Position{-1, -1}
+			p.PlayerState = SelectingPiece
+			p.logger.Debug("promotion cancelled - in selecting piece state")
+		}
 
-				//  move pieces in the model
-				selectedPieceModel := p.SelectedPiecePosition.positionToModel()
-				boardPositionModel := p.BoardPosition.positionToModel()
-				moveStr := selectedPieceModel + boardPositionModel
-				p.logger.Debug(fmt.Sprintf("move string: " + moveStr))
-				g.Model.MoveStr(moveStr)
+	case KeyReserve:
+		if p.IsActive && g.InProgress() {
+			p.toggleReserve(g)
+		}
 
-				p.logger.Debug(g.Model.Position().Board().Draw())
+	case KeyResign:
+		if g.InProgress() && p.Prompt == NoPrompt {
+			p.Prompt = ResignPrompt
+		}
 
-				g.CheckGameState()
-				g.SwitchPlayersIsActive()
-			}
+	case KeyOfferDraw:
+		if g.InProgress() {
+			p.offerDraw(g)
+		}
+
+	case KeyTakeback:
+		if g.InProgress() {
+			p.requestTakeback(g)
 		}
 
+	case KeyClaimDraw:
+		if g.canClaimWin(p) {
+			g.claimWin(p)
+		} else if g.InProgress() {
+			p.claimDraw(g)
+		}
+
+	case KeyHint:
+		if g.InProgress() {
+			g.requestHint(p)
+		}
+
+	case KeyBotLevel:
+		if p.Prompt == BotPrompt && p.botLevel >= minBotLevel && p.botLevel <= maxBotLevel {
+			p.Prompt = NoPrompt
+			g.addBot(p.botLevel)
+		}
+
+	case KeyYes:
+		if g.puzzle != nil && g.puzzle.done && p.Prompt == NoPrompt {
+			g.nextPuzzle(p)
+		} else if g.InProgress() || p.Prompt == BotPrompt {
+			p.answerPrompt(g, true)
+		}
+
+	case KeyNo:
+		p.answerPrompt(g, false)
+
 	default:
 	}
 
 	if p.IsActive && p.PlayerState == SelectingPiece {
 		g.SetBoardColorsSelectingPiece(Position{p.BoardPosition.x, p.BoardPosition.y}, p.PlayerColor)
 
+	} else if p.IsActive && (p.PlayerState == PromotingPiece || p.PlayerState == SelectingReserve) {
+		g.SetPositionColor(*p.BoardPosition, Green)
+
+	} else if p.IsActive && p.PlayerState == DroppingPiece {
+		if g.canDrop(p.PlayerColor.modelColor(), p.DropPieceType, p.BoardPosition.positionToSquare()) {
+			g.SetPositionColor(*p.BoardPosition, Green)
+		} else {
+			g.SetPositionColor(*p.BoardPosition, Red)
+		}
+
 	} else if p.IsActive && p.PlayerState == PlacingPiece {
-		validMoves := g.Model.ValidMoves()
-		validPositions := p.getVaildPositionsForSelectedPiece(validMoves)
+		validPositions := p.validPositions(g)
 		positionIsValid := p.positionInList(validPositions)
 
 		if positionIsValid {
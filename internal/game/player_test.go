@@ -0,0 +1,288 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestGame() *Game {
+	return NewGame(gameWidth, gameHeight, "test", blanklogger.NewBlankLogger())
+}
+
+func newTestPlayer(color ChessPiecesColor) *Player {
+	p := NewPlayer(nil, gameWidth, gameHeight, color.String(), blanklogger.NewBlankLogger())
+	p.PlayerColor = color
+	p.IsActive = true
+	return p
+}
+
+// newTestRoomGame returns a named room created with the options
+func newTestRoomGame(t *testing.T, options RoomOptions) *Game {
+	options.Name = "test"
+	g, err := NewUserCreatedGame(gameWidth, gameHeight, options.Name, options, blanklogger.NewBlankLogger())
+	assert.Nil(t, err, "should be nil")
+	return g
+}
+
+// addTestPlayers seats the players in the game without connecting a session
+func addTestPlayers(g *Game, players ...*Player) {
+	for _, p := range players {
+		g.hub.Sessions[&Session{Player: p}] = struct{}{}
+	}
+}
+
+// newStartedTestGame returns a started game between the white and black
+// players, both are seated and it is white's turn
+func newStartedTestGame(white, black string) (*Game, *Player, *Player) {
+	g := newTestGame()
+	whitePlayer, blackPlayer := newTestPlayer(White), newTestPlayer(Black)
+	whitePlayer.Name, blackPlayer.Name = white, black
+	blackPlayer.IsActive = false
+	addTestPlayers(g, whitePlayer, blackPlayer)
+	g.started = true
+	return g, whitePlayer, blackPlayer
+}
+
+// playMoves moves the pieces for each of the moves given in uci notation
+func playMoves(g *Game, white, black *Player, moves ...string) {
+	for _, m := range moves {
+		p := white
+		if g.Model.Position().Turn() == chess.Black {
+			p = black
+		}
+		from, to := modelToPosition(m[0:2]), modelToPosition(m[2:4])
+		p.SelectedPiecePosition = &from
+		p.BoardPosition = &to
+		p.movePiece(g, chess.NoPieceType)
+	}
+}
+
+// pressKey sends the key to the player and lets the key be released before the
+// next key is pressed
+func pressKey(g *Game, p *Player, key KeyState) {
+	p.currentKeyState = key
+	p.Update(g, 0)
+	p.Update(g, 0)
+}
+
+// startPromotion moves the pawn to the last rank for the move given in uci
+// notation and opens the promotion picker
+func startPromotion(p *Player, move string) {
+	from, to := modelToPosition(move[0:2]), modelToPosition(move[2:4])
+	p.SelectedPiecePosition = &from
+	p.BoardPosition = &to
+	p.PromotionIndex = 0
+	p.PlayerState = PromotingPiece
+}
+
+func Test_MovePiece_Should_Move_The_Rook_When_Castling(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e4", "e7e5", "g1f3", "b8c6", "f1c4", "f8c5", "e1g1")
+
+	assert.Equal(t, chess.WhiteKing, g.pieceAt(modelToPosition("g1")), "king should be on g1")
+	assert.Equal(t, chess.WhiteRook, g.pieceAt(modelToPosition("f1")), "rook should be on f1")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("e1")), "e1 should be empty")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("h1")), "h1 should be empty")
+	assert.Equal(t, whiteRook, g.pieceString(modelToPosition("f1")), "should be equal")
+}
+
+func Test_MovePiece_Should_Remove_The_Captured_Pawn_When_Taking_En_Passant(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	addTestPlayers(g, white, black)
+
+	playMoves(g, white, black, "e2e4", "a7a6", "e4e5", "d7d5", "e5d6")
+
+	assert.Equal(t, chess.WhitePawn, g.pieceAt(modelToPosition("d6")), "pawn should be on d6")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("d5")), "captured pawn should be removed")
+	assert.Equal(t, []string{blackPawn}, white.TakenPiecesList, "should be equal")
+}
+
+func Test_MovePiece_Should_Not_Change_The_Board_When_The_Move_Is_Rejected(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e5")
+
+	assert.Equal(t, chess.WhitePawn, g.pieceAt(modelToPosition("e2")), "pawn should stay on e2")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("e5")), "e5 should be empty")
+	assert.Equal(t, 0, len(g.Model.Moves()), "should be equal")
+}
+
+func Test_Update_Should_Promote_To_The_Chosen_Piece_When_A_Pawn_Reaches_The_Last_Rank(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	addTestPlayers(g, white, black)
+
+	playMoves(g, white, black, "b2b4", "a7a5", "b4a5", "b8c6", "a5a6", "a8b8", "a6b7", "c6d4")
+
+	// select the pawn on b7 and take the bishop on c8
+	white.BoardPosition = &Position{1, 1}
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, PlacingPiece, white.PlayerState, "should be equal")
+
+	pressKey(g, white, KeyRight)
+	pressKey(g, white, KeyUp)
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, PromotingPiece, white.PlayerState, "should be equal")
+
+	// choose the knight
+	pressKey(g, white, KeyRight)
+	pressKey(g, white, KeyRight)
+	pressKey(g, white, KeyRight)
+	pressKey(g, white, KeyRight)
+	assert.Equal(t, 3, white.PromotionIndex, "should be equal")
+	pressKey(g, white, KeyAction)
+
+	assert.Equal(t, SelectingPiece, white.PlayerState, "should be equal")
+	assert.Equal(t, chess.WhiteKnight, g.pieceAt(modelToPosition("c8")), "pawn should be promoted to a knight")
+	assert.Equal(t, chess.NoPiece, g.pieceAt(modelToPosition("b7")), "b7 should be empty")
+	assert.Equal(t, chess.Knight, g.Model.Moves()[len(g.Model.Moves())-1].Promo(), "should be equal")
+	assert.Equal(t, []string{blackPawn, blackPawn, blackBishop}, white.TakenPiecesList, "should be equal")
+}
+
+func Test_Update_Should_Send_The_Promotion_Move_When_The_Piece_Is_Chosen(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "b2b4", "a7a5", "b4a5", "b8c6", "a5a6", "a8b8", "a6b7", "c6d4")
+
+	// the pawn on b7 takes the bishop on c8
+	startPromotion(white, "b7c8")
+
+	// the picker stops at the first and last piece
+	pressKey(g, white, KeyLeft)
+	assert.Equal(t, 0, white.PromotionIndex, "should be equal")
+	for i := 0; i < 5; i++ {
+		pressKey(g, white, KeyDown)
+	}
+	assert.Equal(t, 3, white.PromotionIndex, "should be equal")
+	pressKey(g, white, KeyUp)
+	pressKey(g, white, KeyAction)
+
+	assert.Equal(t, SelectingPiece, white.PlayerState, "should be equal")
+	assert.Equal(t, "b7c8b", g.Model.Moves()[len(g.Model.Moves())-1].String(), "should be equal")
+	assert.Equal(t, chess.WhiteBishop, g.Model.Position().Board().Piece(chess.C8), "pawn should be promoted to a bishop")
+}
+
+func Test_Update_Should_Put_The_Pawn_Back_When_The_Promotion_Is_Cancelled(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "b2b4", "a7a5", "b4a5", "b8c6", "a5a6", "a8b8", "a6b7", "c6d4")
+
+	startPromotion(white, "b7c8")
+	pressKey(g, white, KeyQuit)
+	assert.Equal(t, SelectingPiece, white.PlayerState, "should be equal")
+	assert.Equal(t, chess.WhitePawn, g.Model.Position().Board().Piece(chess.B7), "pawn should still be on b7")
+	assert.Equal(t, 8, len(g.Model.Moves()), "should be equal")
+
+	// the pawn can be promoted once it is picked up again
+	startPromotion(white, "b7c8")
+	pressKey(g, white, KeyAction)
+
+	assert.Equal(t, chess.WhiteQueen, g.Model.Position().Board().Piece(chess.C8), "pawn should be promoted to a queen")
+}
+
+func Test_Update_Should_Resign_The_Game_When_The_Player_Confirms(t *testing.T) {
+	g, _, black := newStartedTestGame("alice", "bob")
+	go func() {
+		for range g.hub.Unregister {
+		}
+	}()
+
+	pressKey(g, black, KeyResign)
+	assert.Equal(t, ResignPrompt, black.Prompt, "should be equal")
+
+	pressKey(g, black, KeyNo)
+	assert.Equal(t, NoPrompt, black.Prompt, "should be equal")
+	assert.True(t, g.InProgress(), "should still be in progress")
+
+	pressKey(g, black, KeyResign)
+	pressKey(g, black, KeyYes)
+	assert.False(t, g.InProgress(), "should not be in progress")
+	assert.Equal(t, chess.WhiteWon, g.Model.Outcome(), "should be equal")
+	assert.Equal(t, chess.Resignation, g.Model.Method(), "should be equal")
+}
+
+func Test_Takeback_Should_Restore_The_Previous_Position_When_Accepted(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.userCreatedGame = true
+
+	playMoves(g, white, black, "e2e4", "d7d5", "e4d5")
+	white.IsActive, black.IsActive = false, true
+	assert.Equal(t, []string{blackPawn}, white.TakenPiecesList, "should be equal")
+
+	pressKey(g, white, KeyTakeback)
+	assert.Equal(t, TakebackPrompt, black.Prompt, "should be equal")
+	pressKey(g, black, KeyYes)
+
+	assert.Equal(t, "takeback accepted", white.Message, "should be equal")
+	assert.Equal(t, 2, len(g.Model.Moves()), "should be equal")
+	assert.Equal(t, chess.WhitePawn, g.pieceAt(modelToPosition("e4")), "pawn should be back on e4")
+	assert.Equal(t, chess.BlackPawn, g.pieceAt(modelToPosition("d5")), "pawn should be back on d5")
+	assert.Equal(t, []string{}, white.TakenPiecesList, "should be equal")
+	assert.True(t, white.IsActive, "white should be active")
+	assert.False(t, black.IsActive, "black should not be active")
+}
+
+func Test_Takeback_Should_Undo_Both_Moves_When_It_Is_The_Players_Turn(t *testing.T) {
+	g := newTestGame()
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+	addTestPlayers(g, white, black)
+
+	playMoves(g, white, black, "e2e4", "d7d5")
+
+	assert.Nil(t, g.Takeback(white), "should be nil")
+	assert.Equal(t, 0, len(g.Model.Moves()), "should be equal")
+	assert.NotNil(t, g.Takeback(white), "should not be nil")
+}
+
+func Test_OfferDraw_Should_Tell_The_Player_When_The_Opponent_Is_Answering_Another_Question(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+
+	pressKey(g, black, KeyResign)
+	pressKey(g, white, KeyOfferDraw)
+
+	assert.Equal(t, "opponent is answering another question", white.Message, "should be equal")
+	assert.Equal(t, ResignPrompt, black.Prompt, "should be equal")
+}
+
+func Test_MoveMade_Should_Decline_The_Draw_Offer_When_The_Opponent_Moves_Instead(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+
+	pressKey(g, white, KeyOfferDraw)
+	playMoves(g, white, black, "e2e4")
+	assert.Equal(t, DrawOfferPrompt, black.Prompt, "should keep the offer made with the move")
+
+	playMoves(g, white, black, "e7e5")
+	assert.Equal(t, NoPrompt, black.Prompt, "should be equal")
+	assert.Equal(t, "draw offer declined", white.Message, "should be equal")
+	assert.True(t, g.InProgress(), "should still be in progress")
+}
+
+func Test_Update_Should_Draw_The_Game_When_Threefold_Repetition_Is_Claimed(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	go func() {
+		for range g.hub.Unregister {
+		}
+	}()
+
+	playMoves(g, white, black, "g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1")
+	pressKey(g, white, KeyClaimDraw)
+	assert.Equal(t, "there is no draw to claim", white.Message, "should be equal")
+	assert.True(t, g.InProgress(), "should still be in progress")
+
+	playMoves(g, white, black, "f6g8")
+	assert.Equal(t, "draw by threefold repetition can be claimed (c)", g.statusString(black), "should be equal")
+
+	pressKey(g, black, KeyClaimDraw)
+	assert.False(t, g.InProgress(), "should not be in progress")
+	assert.Equal(t, chess.Draw, g.Model.Outcome(), "should be equal")
+	assert.Equal(t, chess.ThreefoldRepetition, g.Model.Method(), "should be equal")
+}
@@ -1,5 +1,9 @@
 package game
 
+import (
+	"github.com/notnil/chess"
+)
+
 type Position struct {
 	x int
 	y int
@@ -102,3 +106,12 @@ func modelToPosition(model string) Position {
 	}
 	return Position{posX, posY}
 }
+
+// maps a position to the square used by the model, (0, 0) -> A8 and (7, 7) -> H1
+func (p *Position) positionToSquare() chess.Square {
+	return chess.Square((7-p.y)*8 + p.x)
+}
+
+func squareToPosition(square chess.Square) Position {
+	return Position{int(square.File()), 7 - int(square.Rank())}
+}
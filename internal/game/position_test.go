@@ -4,6 +4,7 @@ import (
 	"reflect"
 	"testing"
 
+	"github.com/notnil/chess"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -80,3 +81,23 @@ func Test_ModelToPosition_Should_Return_Valid_Position_When_Given_A_Position(t *
 		assert.True(t, reflect.DeepEqual(tt.expectedPosition, positionActual), "should be equal")
 	}
 }
+
+func Test_PositionToSquare_Should_Return_Model_Square_When_Given_A_Position(t *testing.T) {
+	tables := []struct {
+		position       Position
+		expectedSquare chess.Square
+	}{
+		{Position{0, 0}, chess.A8},
+		{Position{0, 7}, chess.A1},
+		{Position{4, 6}, chess.E2},
+		{Position{3, 3}, chess.D5},
+		{Position{7, 0}, chess.H8},
+		{Position{7, 7}, chess.H1},
+	}
+
+	for _, tt := range tables {
+		squareActual := tt.position.positionToSquare()
+		assert.Equal(t, tt.expectedSquare, squareActual, "should be equal")
+		assert.Equal(t, tt.position, squareToPosition(squareActual), "should be equal")
+	}
+}
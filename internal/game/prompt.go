@@ -0,0 +1,26 @@
+package game
+
+// PromptType is a yes or no question shown to a player
+type PromptType int
+
+const (
+	NoPrompt PromptType = iota
+	ResignPrompt
+	DrawOfferPrompt
+	TakebackPrompt
+	BotPrompt
+)
+
+func (pt PromptType) String() string {
+	switch pt {
+	case ResignPrompt:
+		return "resign the game? (y/n)"
+	case DrawOfferPrompt:
+		return "your opponent offers a draw. accept? (y/n)"
+	case TakebackPrompt:
+		return "your opponent asks for a takeback. accept? (y/n)"
+	case BotPrompt:
+		return "no opponent yet. play the computer? (y/n or 1-5 for the level)"
+	}
+	return ""
+}
@@ -0,0 +1,151 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/n7down/ssh-chess/internal/puzzle"
+	"github.com/notnil/chess"
+)
+
+// puzzleState is the puzzle being solved and how far the player has got
+type puzzleState struct {
+	store  *puzzle.Store
+	puzzle puzzle.Puzzle
+	// next is the index of the next move of the solution
+	next   int
+	done   bool
+	result string
+}
+
+// NewPuzzleGame returns a game for one player who solves puzzles from the store
+func NewPuzzleGame(worldWidth, worldHeight int, name string, store *puzzle.Store, playerName string, logger logger.Logger) (*Game, error) {
+	g := newGame(worldWidth, worldHeight, name, false, RoomOptions{Name: name}, logger)
+	g.puzzle = &puzzleState{store: store}
+	if err := g.loadPuzzle(playerName); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// minPlayers returns how many players the game needs to start
+func (g *Game) minPlayers() int {
+	if g.puzzle != nil {
+		return 1
+	}
+	return 2
+}
+
+// loadPuzzle sets up the next puzzle for the player and plays the opponent's
+// first move
+func (g *Game) loadPuzzle(playerName string) error {
+	pz, err := g.puzzle.store.Next(playerName)
+	if err != nil {
+		return err
+	}
+
+	// each puzzle starts a new model so no tags are kept from the last one
+	g.Model = chess.NewGame(chess.UseNotation(chess.LongAlgebraicNotation{}))
+	g.Model.AddTagPair("Event", "Puzzle")
+	if err := g.setupFEN(pz.FEN); err != nil {
+		return fmt.Errorf("puzzle %s has an invalid fen: %v", pz.ID, err)
+	}
+	g.Model.AddTagPair("PuzzleId", pz.ID)
+	g.Model.AddTagPair("PuzzleRating", fmt.Sprint(pz.Rating))
+	g.Model.AddTagPair("PuzzleThemes", strings.Join(pz.Themes, " "))
+	g.history = nil
+	g.record = nil
+	g.uciRecord = nil
+	g.drops = map[int]pieceDrop{}
+	g.hint = nil
+	g.puzzle.puzzle = pz
+	g.puzzle.done = false
+	g.puzzle.result = ""
+
+	if err := g.playUCI(g.Model.Position().Turn(), pz.Moves[0]); err != nil {
+		return fmt.Errorf("puzzle %s has an invalid move: %v", pz.ID, err)
+	}
+	g.puzzle.next = 1
+	g.logger.Debug(fmt.Sprintf("loaded puzzle %s for %s", pz.ID, playerName))
+	return nil
+}
+
+// nextPuzzle gives the player the next puzzle once the last one is done
+func (g *Game) nextPuzzle(p *Player) {
+	if err := g.loadPuzzle(p.Name); err != nil {
+		p.Message = err.Error()
+		return
+	}
+	p.Message = ""
+	p.SetColor(colorFromModel(g.Model.Position().Turn()))
+	g.syncActivePlayer()
+	g.updateTakenPieces()
+}
+
+// puzzleMoveMade checks the player's move against the solution and plays the
+// opponent's reply, any move that checkmates solves the puzzle
+func (g *Game) puzzleMoveMade(p *Player) {
+	ps := g.puzzle
+	expected := ps.puzzle.Moves[ps.next]
+	played := g.uciRecord[len(g.uciRecord)-1]
+	lastMove := ps.next == len(ps.puzzle.Moves)-1
+
+	if played != expected && !(lastMove && g.Model.Position().Status() == chess.Checkmate) {
+		positions := g.Model.Positions()
+		solution := puzzleSolution(positions[len(positions)-2], expected)
+		g.finishPuzzle(p, false, fmt.Sprintf("wrong, the solution was %s.", solution))
+		return
+	}
+
+	ps.next++
+	if ps.next >= len(ps.puzzle.Moves) {
+		g.finishPuzzle(p, true, "solved!")
+		return
+	}
+
+	if err := g.playUCI(g.Model.Position().Turn(), ps.puzzle.Moves[ps.next]); err != nil {
+		g.logger.Error(fmt.Sprintf("puzzle %s has an invalid move: %v", ps.puzzle.ID, err.Error()))
+		g.finishPuzzle(p, true, "solved!")
+		return
+	}
+	ps.next++
+	g.updateTakenPieces()
+	p.Message = "correct, keep going"
+}
+
+// puzzleSolution returns the move of the solution from the position in
+// algebraic notation
+func puzzleSolution(position *chess.Position, uci string) string {
+	// the valid move is encoded as only it is tagged with the check
+	for _, move := range position.ValidMoves() {
+		if (chess.UCINotation{}).Encode(position, move) == uci {
+			return chess.AlgebraicNotation{}.Encode(position, move)
+		}
+	}
+	return uci
+}
+
+// finishPuzzle records the result and updates the player's puzzle rating
+func (g *Game) finishPuzzle(p *Player, solved bool, result string) {
+	ps := g.puzzle
+	before := ps.store.Progress(p.Name)
+	after := ps.store.Record(p.Name, ps.puzzle, solved)
+	ps.done = true
+	ps.result = fmt.Sprintf("%s rating %d (%+d)", result, after.Rating, after.Rating-before.Rating)
+	p.Message = ps.result
+	p.IsActive = false
+	g.updateTakenPieces()
+}
+
+// puzzleString returns the puzzle and the player's progress shown in the
+// header
+func (g *Game) puzzleString(p *Player) string {
+	ps := g.puzzle
+	progress := ps.store.Progress(p.Name)
+	s := fmt.Sprintf("puzzle %s (%d) - your rating %d, solved %d of %d ", ps.puzzle.ID, ps.puzzle.Rating, progress.Rating, progress.Solved, progress.Attempted)
+	if ps.done {
+		s += "- y for the next puzzle "
+	}
+	return s
+}
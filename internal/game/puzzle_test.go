@@ -0,0 +1,72 @@
+package game
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/n7down/ssh-chess/internal/puzzle"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestPuzzleGame returns a started puzzle game with mate in two for white
+func newTestPuzzleGame(t *testing.T) (*Game, *Player) {
+	store := puzzle.NewStore([]puzzle.Puzzle{{
+		ID:     "00sHx",
+		FEN:    "q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17",
+		Moves:  []string{"e8d7", "a2e6", "d7d8", "f7f8"},
+		Rating: 1760,
+		Themes: []string{"mate", "mateIn2"},
+	}})
+	g, err := NewPuzzleGame(gameWidth, gameHeight, "test", store, White.String(), blanklogger.NewBlankLogger())
+	assert.Nil(t, err, "should be nil")
+
+	white := newTestPlayer(White)
+	addTestPlayers(g, white)
+	g.started = true
+	return g, white
+}
+
+func Test_NewPuzzleGame_Should_Play_The_Opponents_Move_When_The_Puzzle_Is_Loaded(t *testing.T) {
+	g, _ := newTestPuzzleGame(t)
+
+	assert.Equal(t, []string{"e8d7"}, g.uciRecord, "should be equal")
+	assert.Equal(t, 1, g.minPlayers(), "should be equal")
+	assert.True(t, strings.Contains(g.PGN(), "[PuzzleId \"00sHx\"]"), "should tag the puzzle")
+	assert.True(t, strings.Contains(g.PGN(), "[SetUp \"1\"]"), "should tag the position")
+}
+
+func Test_PuzzleMoveMade_Should_Solve_The_Puzzle_When_The_Solution_Is_Played(t *testing.T) {
+	g, white := newTestPuzzleGame(t)
+
+	playMoves(g, white, nil, "a2e6")
+	assert.Equal(t, "correct, keep going", white.Message, "should be equal")
+	assert.Equal(t, []string{"e8d7", "a2e6", "d7d8"}, g.uciRecord, "should be equal")
+	assert.True(t, white.IsActive, "white should be active")
+
+	playMoves(g, white, nil, "f7f8")
+	assert.Equal(t, "solved! rating 1526 (+26)", white.Message, "should be equal")
+	assert.True(t, g.puzzle.done, "should be done")
+	assert.False(t, white.IsActive, "white should not be active")
+	assert.False(t, g.Ended(), "should not end the game")
+	assert.Equal(t, puzzle.Progress{Rating: 1526, Solved: 1, Attempted: 1}, g.puzzle.store.Progress(white.Name), "should be equal")
+	assert.True(t, strings.Contains(g.puzzleString(white), "y for the next puzzle"), "should offer the next puzzle")
+
+	pressKey(g, white, KeyYes)
+	assert.False(t, g.puzzle.done, "should not be done")
+	assert.Equal(t, []string{"e8d7"}, g.uciRecord, "should be equal")
+	assert.True(t, white.IsActive, "white should be active")
+}
+
+func Test_PuzzleMoveMade_Should_Fail_The_Puzzle_When_Another_Move_Is_Played(t *testing.T) {
+	g, white := newTestPuzzleGame(t)
+
+	playMoves(g, white, nil, "f7g7")
+
+	assert.Equal(t, "wrong, the solution was Be6+. rating 1494 (-6)", white.Message, "should be equal")
+	assert.Equal(t, puzzle.Progress{Rating: 1494, Solved: 0, Attempted: 1}, g.puzzle.store.Progress(white.Name), "should be equal")
+
+	// giving up after the puzzle is done does not count it again
+	g.Resign(white)
+	assert.Equal(t, 1, g.puzzle.store.Progress(white.Name).Attempted, "should be equal")
+}
@@ -0,0 +1,212 @@
+package game
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/notnil/chess"
+)
+
+// abandonment is the method of a game won because the opponent did not
+// reconnect
+const abandonment = "abandonment"
+
+// heldSeat is the seat of a player whose connection dropped, the player can
+// reconnect to it until the grace period is over
+type heldSeat struct {
+	identity string
+	since    time.Time
+}
+
+// playerIdentity returns who a player is when reconnecting, the key's
+// fingerprint for players who used a key and the name otherwise
+func playerIdentity(name, fingerprint string) string {
+	if fingerprint != "" {
+		return "key:" + fingerprint
+	}
+	return "name:" + name
+}
+
+// tokenIdentity returns who a guest is when reconnecting with the token
+func tokenIdentity(token string) string {
+	return "token:" + token
+}
+
+// newReconnectToken returns a token a guest reconnects with, it can only be
+// used once
+func newReconnectToken() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// identity returns who the session's player is when reconnecting, a guest can
+// only be told apart by the token sent to them and is empty without one
+func (s *Session) identity() string {
+	if s.Guest {
+		if s.Player.reconnectToken == "" {
+			return ""
+		}
+		return tokenIdentity(s.Player.reconnectToken)
+	}
+	return playerIdentity(s.Player.Name, s.Fingerprint)
+}
+
+// tokenString returns how a guest reconnects to the game in progress
+func (g *Game) tokenString(p *Player) string {
+	if p.reconnectToken == "" || g.reconnectGrace <= 0 || !g.InProgress() {
+		return ""
+	}
+	return fmt.Sprintf("if your connection drops run: reconnect %s", p.reconnectToken)
+}
+
+// canHold returns true when the seat of a player whose connection dropped is
+// held for the player to reconnect to
+func (g *Game) canHold(p *Player) bool {
+	return g.reconnectGrace > 0 && g.InProgress() && g.opponent(p) != nil
+}
+
+// hold keeps the player in the game without a session until the player
+// reconnects or the grace period is over, the clock keeps running
+func (g *Game) hold(p *Player, identity string) {
+	g.heldMutex.Lock()
+	g.held[p] = heldSeat{identity: identity, since: time.Now()}
+	g.heldMutex.Unlock()
+	p.s = nil
+	g.logger.Debug(fmt.Sprintf("%s disconnected, holding the seat for %s", p.Name, g.reconnectGrace))
+}
+
+// connectionDropped passes the session whose connection dropped to the game
+// loop
+func (g *Game) connectionDropped(s *Session) {
+	g.dropped <- s
+}
+
+// updateDropped holds the seats of players whose connections dropped in a game
+// in progress and removes them from other games, a session that was already
+// removed from its game is ignored
+func (g *Game) updateDropped() {
+	for {
+		select {
+		case s := <-g.dropped:
+			if !g.hasSession(s) {
+				continue
+			}
+			identity := s.identity()
+			if identity == "" || !g.canHold(s.Player) {
+				g.leave(s)
+				continue
+			}
+			g.hold(s.Player, identity)
+			g.RemoveSession(s, "")
+		default:
+			return
+		}
+	}
+}
+
+// heldPlayers returns the players whose seats are held and when their
+// connections dropped
+func (g *Game) heldPlayers() map[*Player]time.Time {
+	g.heldMutex.Lock()
+	defer g.heldMutex.Unlock()
+	players := map[*Player]time.Time{}
+	for player, seat := range g.held {
+		players[player] = seat.since
+	}
+	return players
+}
+
+// heldPlayer returns the player with the identity whose seat is held, nil when
+// there is none
+func (g *Game) heldPlayer(identity string) *Player {
+	g.heldMutex.Lock()
+	defer g.heldMutex.Unlock()
+	for player, seat := range g.held {
+		if seat.identity == identity {
+			return player
+		}
+	}
+	return nil
+}
+
+// reattach gives the held seat back to the player with the session, the
+// player keeps its color, clock and state
+func (g *Game) reattach(p *Player, s *Session) {
+	g.stateMutex.Lock()
+	defer g.stateMutex.Unlock()
+
+	g.heldMutex.Lock()
+	delete(g.held, p)
+	g.heldMutex.Unlock()
+
+	p.s = s
+	p.Message = "reconnected"
+	if opponent := g.opponent(p); opponent != nil {
+		opponent.Message = fmt.Sprintf("%s reconnected", p.Name)
+	}
+	g.logger.Debug(fmt.Sprintf("%s reconnected", p.Name))
+}
+
+// abandoned returns true when the player's seat is held and the grace period
+// is over
+func (g *Game) abandoned(p *Player) bool {
+	since, ok := g.heldPlayers()[p]
+	return ok && time.Since(since) >= g.reconnectGrace
+}
+
+// canClaimWin returns true when the player's opponent did not reconnect in
+// time
+func (g *Game) canClaimWin(p *Player) bool {
+	opponent := g.opponent(p)
+	return g.InProgress() && opponent != nil && !g.abandoned(p) && g.abandoned(opponent)
+}
+
+// claimWin ends the game as a win for the player whose opponent did not
+// reconnect
+func (g *Game) claimWin(p *Player) {
+	g.logger.Debug(fmt.Sprintf("%s claimed the win by %s", p.Name, abandonment))
+	g.endGame(winner(p.PlayerColor.modelColor()), abandonment)
+}
+
+// checkAbandoned claims the win for a bot whose opponent did not reconnect and
+// draws the game when neither player did
+func (g *Game) checkAbandoned() {
+	if !g.InProgress() {
+		return
+	}
+	for player := range g.heldPlayers() {
+		if !g.abandoned(player) {
+			continue
+		}
+		opponent := g.opponent(player)
+		if opponent == nil || g.abandoned(opponent) {
+			g.endGame(chess.Draw, abandonment)
+			return
+		}
+		if opponent.IsBot() {
+			g.claimWin(opponent)
+			return
+		}
+	}
+}
+
+// reconnectString returns the status of the opponent while the opponent's seat
+// is held
+func (g *Game) reconnectString(p *Player) string {
+	opponent := g.opponent(p)
+	if opponent == nil || !g.InProgress() {
+		return ""
+	}
+	since, ok := g.heldPlayers()[opponent]
+	if !ok {
+		return ""
+	}
+	if left := g.reconnectGrace - time.Since(since); left > 0 {
+		return fmt.Sprintf("%s disconnected, waiting %ds for them to reconnect", opponent.Name, int(math.Ceil(left.Seconds())))
+	}
+	return fmt.Sprintf("%s did not reconnect, claim the win (c)", opponent.Name)
+}
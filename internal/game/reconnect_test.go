@@ -0,0 +1,168 @@
+package game
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+// dropConnection holds the player's seat and removes the player's session
+// like the game manager does when the connection drops
+func dropConnection(g *Game, p *Player, since time.Time) {
+	for s := range g.hub.Sessions {
+		if s.Player == p {
+			delete(g.hub.Sessions, s)
+		}
+	}
+	g.hold(p, playerIdentity(p.Name, ""))
+	g.held[p] = heldSeat{identity: g.held[p].identity, since: since}
+}
+
+func Test_CanHold_Should_Return_False_When_The_Seat_Can_Not_Be_Held(t *testing.T) {
+	g, white, _ := newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	assert.True(t, g.canHold(white), "should be true")
+
+	g.reconnectGrace = 0
+	assert.False(t, g.canHold(white), "should not hold without a grace period")
+
+	g, white, _ = newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	g.started = false
+	assert.False(t, g.canHold(white), "should not hold before the game starts")
+
+	g = newTestGame()
+	g.reconnectGrace = time.Minute
+	g.started = true
+	alone := newTestPlayer(White)
+	addTestPlayers(g, alone)
+	assert.False(t, g.canHold(alone), "should not hold without an opponent")
+}
+
+func Test_Identity_Should_Need_The_Token_When_The_Player_Is_A_Guest(t *testing.T) {
+	alice := newTestPlayer(White)
+	alice.Name = "alice"
+	s := &Session{Player: alice, Fingerprint: "SHA256:abc"}
+	assert.Equal(t, "key:SHA256:abc", s.identity(), "should be equal")
+
+	s = &Session{Player: alice}
+	assert.Equal(t, "name:alice", s.identity(), "should be equal")
+
+	s = &Session{Player: alice, Guest: true}
+	assert.Equal(t, "", s.identity(), "should not hold a guest's seat without a token")
+
+	alice.reconnectToken = "0123456789abcdef"
+	assert.Equal(t, "token:0123456789abcdef", s.identity(), "should be equal")
+}
+
+func Test_StatusString_Should_Show_The_Token_When_The_Player_Is_A_Guest(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	white.reconnectToken = "0123456789abcdef"
+
+	assert.Equal(t, "if your connection drops run: reconnect 0123456789abcdef", g.statusString(white), "should be equal")
+	assert.Equal(t, "", g.statusString(black), "should be equal")
+}
+
+func Test_Hold_Should_Keep_The_Player_When_The_Connection_Drops(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	playMoves(g, white, black, "e2e4", "e7e5")
+	black.Message = "alice reconnected"
+
+	dropConnection(g, white, time.Now())
+
+	assert.Equal(t, 2, g.PlayerCount(), "should be equal")
+	assert.Equal(t, 1, g.SessionCount(), "should be equal")
+	assert.Equal(t, white, g.opponent(black), "should be equal")
+	assert.Equal(t, white, g.heldPlayer("name:alice"), "should be equal")
+	assert.Nil(t, g.heldPlayer("name:bob"), "should be nil")
+	assert.Equal(t, "alice disconnected, waiting 60s for them to reconnect", g.statusString(black), "should be equal")
+	assert.False(t, g.canClaimWin(black), "should not claim the win during the grace period")
+}
+
+func Test_Reattach_Should_Keep_The_Players_State_When_The_Player_Reconnects(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	playMoves(g, white, black, "e2e4", "e7e5")
+	white.BoardPosition = &Position{6, 7}
+
+	dropConnection(g, white, time.Now())
+	session := resumeSession(nil, white, blanklogger.NewBlankLogger())
+	g.reattach(white, session)
+
+	assert.Equal(t, 0, len(g.heldPlayers()), "should be equal")
+	assert.Equal(t, session, white.s, "should be equal")
+	assert.Equal(t, White, white.PlayerColor, "should be equal")
+	assert.True(t, white.IsActive, "should be true")
+	assert.Equal(t, Position{6, 7}, *white.BoardPosition, "should be equal")
+	assert.Equal(t, "reconnected", white.Message, "should be equal")
+	assert.Equal(t, "alice reconnected", black.Message, "should be equal")
+
+	pressKey(g, white, KeyAction)
+	assert.Equal(t, PlacingPiece, white.PlayerState, "should pick up the knight")
+}
+
+func Test_ClaimWin_Should_End_The_Game_When_The_Opponent_Did_Not_Reconnect(t *testing.T) {
+	g, white, black := newStartedTestGame("alice", "bob")
+	g.reconnectGrace = time.Minute
+	playMoves(g, white, black, "e2e4", "e7e5")
+	go func() {
+		for range g.hub.Unregister {
+		}
+	}()
+
+	dropConnection(g, white, time.Now().Add(-2*time.Minute))
+	assert.Equal(t, "alice did not reconnect, claim the win (c)", g.statusString(black), "should be equal")
+	assert.True(t, g.canClaimWin(black), "should be true")
+	assert.False(t, g.canClaimWin(white), "should be false")
+
+	pressKey(g, black, KeyClaimDraw)
+	outcome, method := g.Outcome()
+	assert.True(t, g.Ended(), "should be true")
+	assert.Equal(t, chess.BlackWon, outcome, "should be equal")
+	assert.Equal(t, abandonment, method, "should be equal")
+	assert.True(t, strings.Contains(g.PGN(), "[Termination \"abandonment\"]"), "should be true")
+}
+
+func Test_CheckAbandoned_Should_End_The_Game_When_The_Grace_Period_Is_Over(t *testing.T) {
+	tests := []struct {
+		bot      bool
+		since    time.Duration
+		outcome  chess.Outcome
+		expected bool
+	}{
+		{true, -2 * time.Minute, chess.BlackWon, true},
+		{true, -30 * time.Second, chess.NoOutcome, false},
+		{false, -2 * time.Minute, chess.Draw, true},
+	}
+
+	for _, test := range tests {
+		var g *Game
+		if test.bot {
+			g = newTestGame()
+			g.started = true
+			white := newTestPlayer(White)
+			addTestPlayers(g, white)
+			bot := NewBotPlayer(&testBot{}, "computer", blanklogger.NewBlankLogger())
+			bot.SetColor(Black)
+			g.bots = append(g.bots, bot)
+			dropConnection(g, white, time.Now().Add(test.since))
+		} else {
+			var white, black *Player
+			g, white, black = newStartedTestGame("alice", "bob")
+			dropConnection(g, white, time.Now().Add(test.since))
+			dropConnection(g, black, time.Now().Add(test.since))
+		}
+		g.reconnectGrace = time.Minute
+
+		g.checkAbandoned()
+		outcome, _ := g.Outcome()
+		assert.Equal(t, test.expected, g.Ended(), "should be equal")
+		assert.Equal(t, test.outcome, outcome, "should be equal")
+	}
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	minutesRegexp   = regexp.MustCompile(`^\d+(\.\d+)?$`)
+	timeBonusRegexp = regexp.MustCompile(`^[db]?\d+$`)
+	chess960Regexp  = regexp.MustCompile(`^chess960(=\d+)?$`)
+)
+
+// RoomOptions are the options a player gives after the room name when creating
+// a room, for example alice#room+5+3, alice#room+chess960=518 or
+// alice#room+odds=queen
+type RoomOptions struct {
+	Name        string
+	TimeControl *TimeControl
+	Chess960    bool
+	// Chess960Index is the starting position, a negative index is random
+	Chess960Index int
+	// FEN is the position the game starts from
+	FEN string
+	// Variant is the name of the variant the room plays, empty for standard
+	Variant string
+	// Rated games do not give hints
+	Rated bool
+	// Odds is the handicap the player who creates the room gives, empty for an
+	// even game
+	Odds string
+	// OddsTime is the time the player giving time odds starts with
+	OddsTime time.Duration
+}
+
+func ParseRoomOptions(room string) (RoomOptions, error) {
+	tokens := strings.Split(room, "+")
+	options := RoomOptions{
+		Name:          tokens[0],
+		Chess960Index: -1,
+	}
+
+	for i := 1; i < len(tokens); i++ {
+		token := strings.ToLower(tokens[i])
+		switch {
+		case minutesRegexp.MatchString(token) && options.TimeControl == nil:
+			timeControlString := token
+			if i+1 < len(tokens) && timeBonusRegexp.MatchString(strings.ToLower(tokens[i+1])) {
+				timeControlString += "+" + tokens[i+1]
+				i++
+			}
+
+			tc, err := ParseTimeControl(timeControlString)
+			if err != nil {
+				return options, err
+			}
+			options.TimeControl = &tc
+		case chess960Regexp.MatchString(token):
+			options.Chess960 = true
+			if strings.Contains(token, "=") {
+				indexString := strings.TrimPrefix(token, "chess960=")
+				index, err := strconv.Atoi(indexString)
+				if err != nil || index >= chess960Positions {
+					return options, fmt.Errorf("chess960 starting position must be between 0 and %d: %s", chess960Positions-1, indexString)
+				}
+				options.Chess960Index = index
+			}
+		case variants[token] != nil:
+			if options.Variant != "" {
+				return options, fmt.Errorf("a room can only play one variant: %s and %s", options.Variant, token)
+			}
+			options.Variant = token
+		case token == "rated":
+			options.Rated = true
+		case strings.HasPrefix(token, "odds="):
+			if err := options.setOdds(strings.TrimPrefix(token, "odds=")); err != nil {
+				return options, err
+			}
+		case strings.HasPrefix(token, "fen="):
+			// spaces are not allowed in ssh user names so the fields of the FEN
+			// are separated with underscores
+			fen := strings.ReplaceAll(tokens[i][len("fen="):], "_", " ")
+			if err := options.SetFEN(fen); err != nil {
+				return options, err
+			}
+		default:
+			return options, fmt.Errorf("unknown room option: %s", tokens[i])
+		}
+	}
+
+	if options.Chess960 && options.FEN != "" {
+		return options, errors.New("a room can not be both chess960 and start from a fen")
+	}
+	if options.Odds != "" && (options.Chess960 || options.FEN != "") {
+		return options, errors.New("odds can only be given from the starting position")
+	}
+	if options.Odds == timeOdds && options.TimeControl == nil {
+		return options, errors.New("time odds need a time control")
+	}
+	return options, nil
+}
+
+// SetFEN sets the position the game starts from after checking the FEN can be
+// played
+func (o *RoomOptions) SetFEN(fen string) error {
+	fen = strings.TrimSpace(fen)
+	if err := validateFEN(fen); err != nil {
+		return err
+	}
+	o.FEN = fen
+	return nil
+}
@@ -1,6 +1,7 @@
 package game
 
 import (
+	"sync"
 	"time"
 
 	"github.com/n7down/ssh-chess/internal/logger"
@@ -13,7 +14,15 @@ type Session struct {
 	LastAction time.Time
 	HighScore  int
 	Player     *Player
-	logger     logger.Logger
+	// Fingerprint is the fingerprint of the key the player authenticated
+	// with, empty when the player did not use a key
+	Fingerprint string
+	// Guest is true when the player connected without a key or a password
+	Guest     bool
+	size      TerminalSize
+	sizeMutex sync.Mutex
+	protocol  *botProtocol
+	logger    logger.Logger
 }
 
 func NewSession(c ssh.Channel, worldWidth, worldHeight int, playerName string, logger logger.Logger) *Session {
@@ -28,10 +37,34 @@ func NewSession(c ssh.Channel, worldWidth, worldHeight int, playerName string, l
 	return &s
 }
 
+// resumeSession returns a session for the player whose connection dropped
+func resumeSession(c ssh.Channel, player *Player, logger logger.Logger) *Session {
+	return &Session{
+		c:          c,
+		LastAction: time.Now(),
+		Player:     player,
+		logger:     logger,
+	}
+}
+
 func (s *Session) newGame(worldWidth, worldHeight int, playerName string) {
 	s.Player = NewPlayer(s, worldWidth, worldHeight, playerName, s.logger)
 }
 
+// Resize sets the size of the player's terminal, the game is drawn to fit it
+func (s *Session) Resize(size TerminalSize) {
+	s.sizeMutex.Lock()
+	s.size = size
+	s.sizeMutex.Unlock()
+}
+
+// TerminalSize returns the size of the player's terminal
+func (s *Session) TerminalSize() TerminalSize {
+	s.sizeMutex.Lock()
+	defer s.sizeMutex.Unlock()
+	return s.size
+}
+
 func (s *Session) didAction() {
 	s.LastAction = time.Now()
 }
@@ -0,0 +1,44 @@
+package game
+
+import (
+	"golang.org/x/crypto/ssh"
+)
+
+// TerminalSize is the number of columns and rows of a player's terminal, it is
+// zero when the player did not ask for a terminal
+type TerminalSize struct {
+	Width  int
+	Height int
+}
+
+// ParsePtyRequest returns the terminal size from the payload of a pty-req
+// request
+func ParsePtyRequest(payload []byte) (TerminalSize, error) {
+	request := struct {
+		Term    string
+		Columns uint32
+		Rows    uint32
+		Width   uint32
+		Height  uint32
+		Modes   string
+	}{}
+	if err := ssh.Unmarshal(payload, &request); err != nil {
+		return TerminalSize{}, err
+	}
+	return TerminalSize{Width: int(request.Columns), Height: int(request.Rows)}, nil
+}
+
+// ParseWindowChange returns the terminal size from the payload of a
+// window-change request
+func ParseWindowChange(payload []byte) (TerminalSize, error) {
+	request := struct {
+		Columns uint32
+		Rows    uint32
+		Width   uint32
+		Height  uint32
+	}{}
+	if err := ssh.Unmarshal(payload, &request); err != nil {
+		return TerminalSize{}, err
+	}
+	return TerminalSize{Width: int(request.Columns), Height: int(request.Rows)}, nil
+}
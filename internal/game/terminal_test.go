@@ -0,0 +1,42 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func Test_ParsePtyRequest_Should_Return_The_Size_When_Given_The_Payload(t *testing.T) {
+	payload := ssh.Marshal(struct {
+		Term    string
+		Columns uint32
+		Rows    uint32
+		Width   uint32
+		Height  uint32
+		Modes   string
+	}{"xterm-256color", 120, 40, 0, 0, ""})
+
+	size, err := ParsePtyRequest(payload)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, TerminalSize{Width: 120, Height: 40}, size, "should be equal")
+
+	_, err = ParsePtyRequest([]byte{0, 0})
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_ParseWindowChange_Should_Return_The_Size_When_Given_The_Payload(t *testing.T) {
+	payload := ssh.Marshal(struct {
+		Columns uint32
+		Rows    uint32
+		Width   uint32
+		Height  uint32
+	}{60, 20, 0, 0})
+
+	size, err := ParseWindowChange(payload)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, TerminalSize{Width: 60, Height: 20}, size, "should be equal")
+
+	_, err = ParseWindowChange(nil)
+	assert.NotNil(t, err, "should not be nil")
+}
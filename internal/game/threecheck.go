@@ -0,0 +1,56 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+const threeCheckChecks = 3
+
+// ThreeCheck is won by checkmate or by giving check three times
+type ThreeCheck struct{}
+
+func (ThreeCheck) Name() string {
+	return "Three-check"
+}
+
+func (ThreeCheck) Setup(g *Game) error {
+	g.Model.AddTagPair("Variant", "Three-check")
+	return nil
+}
+
+func (ThreeCheck) ValidMoves(g *Game, moves []*chess.Move) []*chess.Move {
+	return moves
+}
+
+func (v ThreeCheck) Outcome(g *Game) (chess.Outcome, string) {
+	checks := v.checks(g)
+	for _, color := range []chess.Color{chess.White, chess.Black} {
+		if checks[color] >= threeCheckChecks {
+			return winner(color), "three checks"
+		}
+	}
+	return modelOutcome(g)
+}
+
+func (v ThreeCheck) Status(g *Game) string {
+	checks := v.checks(g)
+	return fmt.Sprintf("+%d +%d", checks[chess.White], checks[chess.Black])
+}
+
+func (ThreeCheck) TakenPieces(g *Game, color chess.Color) []string {
+	return g.capturedPieces(color)
+}
+
+// checks returns the number of checks given by each color
+func (ThreeCheck) checks(g *Game) map[chess.Color]int {
+	checks := map[chess.Color]int{}
+	for i, san := range g.record {
+		if strings.HasSuffix(san, "+") || strings.HasSuffix(san, "#") {
+			checks[g.moveColor(i)]++
+		}
+	}
+	return checks
+}
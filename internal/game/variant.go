@@ -0,0 +1,92 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// Variant changes the rules of a game. The game asks the variant for the
+// moves that can be played and whether the game is over so new rules can be
+// added without changing the game
+type Variant interface {
+	// Name is shown in the room's header and used for the PGN Variant tag
+	Name() string
+
+	// Setup is called when the game is created and can change the starting
+	// position
+	Setup(g *Game) error
+
+	// ValidMoves returns the moves that can be played out of the moves the
+	// model allows
+	ValidMoves(g *Game, moves []*chess.Move) []*chess.Move
+
+	// Outcome is checked after every move, the game ends with the method when
+	// the outcome is not chess.NoOutcome
+	Outcome(g *Game) (chess.Outcome, string)
+
+	// Status returns extra information shown in the room's header
+	Status(g *Game) string
+
+	// TakenPieces returns the pieces shown beside the board for the color
+	TakenPieces(g *Game, color chess.Color) []string
+}
+
+// the variants that can be given as a room option, a new variant is added by
+// adding it here
+var variants = map[string]func() Variant{
+	"kingofthehill": func() Variant { return KingOfTheHill{} },
+	"koth":          func() Variant { return KingOfTheHill{} },
+	"threecheck":    func() Variant { return ThreeCheck{} },
+	"3check":        func() Variant { return ThreeCheck{} },
+	"crazyhouse":    func() Variant { return Crazyhouse{} },
+	"zh":            func() Variant { return Crazyhouse{} },
+}
+
+// NewVariant returns the variant with the name, an empty name is standard chess
+func NewVariant(name string) (Variant, error) {
+	if name == "" {
+		return Standard{}, nil
+	}
+	newVariant, ok := variants[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unknown variant: %s", name)
+	}
+	return newVariant(), nil
+}
+
+// Standard is chess without any changes to the rules
+type Standard struct{}
+
+func (Standard) Name() string {
+	return "Standard"
+}
+
+func (Standard) Setup(g *Game) error {
+	return nil
+}
+
+func (Standard) ValidMoves(g *Game, moves []*chess.Move) []*chess.Move {
+	return moves
+}
+
+func (Standard) Outcome(g *Game) (chess.Outcome, string) {
+	return modelOutcome(g)
+}
+
+func (Standard) Status(g *Game) string {
+	return ""
+}
+
+func (Standard) TakenPieces(g *Game, color chess.Color) []string {
+	return g.capturedPieces(color)
+}
+
+// winner returns the outcome where the color wins
+func winner(color chess.Color) chess.Outcome {
+	if color == chess.White {
+		return chess.WhiteWon
+	}
+	return chess.BlackWon
+}
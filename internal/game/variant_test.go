@@ -0,0 +1,50 @@
+package game
+
+import (
+	"testing"
+
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ParseRoomOptions_Should_Return_The_Variant_When_Given_A_Variant(t *testing.T) {
+	options, err := ParseRoomOptions("room+koth+5+3")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "koth", options.Variant, "should be equal")
+
+	_, err = ParseRoomOptions("room+koth+threecheck")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_NewVariant_Should_Return_Error_When_Given_An_Unknown_Variant(t *testing.T) {
+	_, err := NewVariant("atomic")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_KingOfTheHill_Should_End_The_Game_When_A_King_Reaches_The_Center(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "kingofthehill"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "d2d3", "e7e6", "e1d2", "a7a6", "d2e3", "a6a5")
+	assert.False(t, g.Ended(), "should not have ended")
+
+	playMoves(g, white, black, "e3e4")
+	assert.True(t, g.Ended(), "should have ended")
+	assert.Equal(t, chess.WhiteWon, g.outcome, "should be equal")
+	assert.Equal(t, "king in the center", g.method, "should be equal")
+	assert.Contains(t, g.PGN(), "[Variant \"King of the Hill\"]", "should contain the variant")
+}
+
+func Test_ThreeCheck_Should_End_The_Game_When_A_Player_Gives_Three_Checks(t *testing.T) {
+	g := newTestRoomGame(t, RoomOptions{Variant: "threecheck"})
+	white, black := newTestPlayer(White), newTestPlayer(Black)
+
+	playMoves(g, white, black, "e2e4", "d7d5", "f1b5", "c7c6", "b5c6", "b7c6", "d1f3", "a7a6")
+	assert.Equal(t, "+2 +0", g.variant.Status(g), "should be equal")
+	assert.False(t, g.Ended(), "should not have ended")
+
+	playMoves(g, white, black, "f3f7")
+	assert.True(t, g.Ended(), "should have ended")
+	assert.Equal(t, chess.WhiteWon, g.outcome, "should be equal")
+	assert.Equal(t, "three checks", g.method, "should be equal")
+}
@@ -0,0 +1,134 @@
+package match
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/n7down/ssh-chess/internal/game"
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/notnil/chess"
+)
+
+// Engine is one of the two sides of a match
+type Engine struct {
+	Name string
+	// NewBot returns the bot that plays one game
+	NewBot func() game.Bot
+}
+
+// Config is the match to play
+type Config struct {
+	// Engines are the two engines, the summary is for the first engine
+	Engines [2]Engine
+	Games   int
+	// Concurrency is how many games are played at once
+	Concurrency int
+	// Options set up every game like a named room, for example the time
+	// control
+	Options game.RoomOptions
+	// Openings are FENs the games start from, each opening is played twice so
+	// each engine plays both colors
+	Openings []string
+}
+
+// Result is one finished game of the match
+type Result struct {
+	// Round is the number of the game from 1
+	Round   int
+	Outcome chess.Outcome
+	Method  string
+	PGN     string
+	// FirstIsWhite is true when the first engine played white
+	FirstIsWhite bool
+	Err          error
+}
+
+// score returns the first engine's score for the game
+func (r Result) score() float64 {
+	switch {
+	case r.Outcome == chess.Draw:
+		return 0.5
+	case (r.Outcome == chess.WhiteWon) == r.FirstIsWhite:
+		return 1
+	}
+	return 0
+}
+
+// Run plays the games of the match and passes each result to onResult as it
+// finishes, the engines swap colors after every game
+func Run(config Config, logger logger.Logger, onResult func(Result)) (Summary, error) {
+	if config.Games <= 0 {
+		return Summary{}, errors.New("a match needs at least one game")
+	}
+	if config.Concurrency <= 0 {
+		config.Concurrency = 1
+	}
+
+	rounds := make(chan int)
+	go func() {
+		for round := 1; round <= config.Games; round++ {
+			rounds <- round
+		}
+		close(rounds)
+	}()
+
+	summary := Summary{}
+	mutex := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < config.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := range rounds {
+				result := playGame(config, round, logger)
+				mutex.Lock()
+				if result.Err == nil {
+					summary.Add(result.score())
+				}
+				onResult(result)
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return summary, nil
+}
+
+// playGame plays the round through the game loop with the first engine white in
+// odd rounds
+func playGame(config Config, round int, logger logger.Logger) Result {
+	firstIsWhite := round%2 == 1
+	white, black := config.Engines[0], config.Engines[1]
+	if !firstIsWhite {
+		white, black = black, white
+	}
+
+	options := config.Options
+	if len(config.Openings) > 0 {
+		options.FEN = config.Openings[((round-1)/2)%len(config.Openings)]
+	}
+
+	g, err := game.NewBotGame(
+		fmt.Sprintf("round %d", round),
+		game.NewBotPlayer(white.NewBot(), white.Name, logger),
+		game.NewBotPlayer(black.NewBot(), black.Name, logger),
+		options,
+		logger,
+	)
+	if err != nil {
+		return Result{Round: round, FirstIsWhite: firstIsWhite, Err: err}
+	}
+	g.Model.AddTagPair("Event", "engine match")
+	g.Model.AddTagPair("Round", fmt.Sprint(round))
+
+	g.Play()
+	outcome, method := g.Outcome()
+	return Result{
+		Round:        round,
+		Outcome:      outcome,
+		Method:       method,
+		PGN:          g.PGN(),
+		FirstIsWhite: firstIsWhite,
+	}
+}
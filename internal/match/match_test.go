@@ -0,0 +1,75 @@
+package match
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/n7down/ssh-chess/internal/game"
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+// resigningBot resigns by failing to choose a move
+type resigningBot struct{}
+
+func (resigningBot) Move(position *chess.Position) (*chess.Move, error) {
+	return nil, errors.New("no move")
+}
+
+func Test_Summary_Should_Estimate_The_Elo_Difference_When_Given_Results(t *testing.T) {
+	tests := []struct {
+		summary  Summary
+		score    float64
+		elo      float64
+		expected string
+	}{
+		{Summary{Wins: 5, Draws: 0, Losses: 5}, 0.5, 0, "+5 =0 -5 (50.0%) elo +0 ± 252"},
+		{Summary{Wins: 6, Draws: 3, Losses: 1}, 0.75, 190.8, "+6 =3 -1 (75.0%) elo +191 ± 257"},
+		{Summary{Wins: 1, Draws: 3, Losses: 6}, 0.25, -190.8, "+1 =3 -6 (25.0%) elo -191 ± 257"},
+		{Summary{Wins: 0, Draws: 4, Losses: 0}, 0.5, 0, "+0 =4 -0 (50.0%) elo +0 ± 0"},
+	}
+
+	for _, test := range tests {
+		elo, _ := test.summary.Elo()
+		assert.Equal(t, test.score, test.summary.Score(), "should be equal")
+		assert.InDelta(t, test.elo, elo, 0.1, "should be equal")
+		assert.Equal(t, test.expected, test.summary.String(), "should be equal")
+	}
+}
+
+func Test_Summary_Should_Return_An_Infinite_Difference_When_An_Engine_Scored_Every_Point(t *testing.T) {
+	elo, _ := Summary{Wins: 3}.Elo()
+	assert.True(t, math.IsInf(elo, 1), "should be infinite")
+}
+
+func Test_Run_Should_Swap_Colors_When_Playing_Each_Game(t *testing.T) {
+	newBot := func() game.Bot {
+		return resigningBot{}
+	}
+	config := Config{
+		Engines: [2]Engine{{Name: "alice", NewBot: newBot}, {Name: "bob", NewBot: newBot}},
+		Games:   4,
+		Options: game.RoomOptions{Name: "match"},
+	}
+
+	results := []Result{}
+	summary, err := Run(config, blanklogger.NewBlankLogger(), func(result Result) {
+		results = append(results, result)
+	})
+
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, Summary{Wins: 2, Losses: 2}, summary, "should be equal")
+	assert.Equal(t, 4, len(results), "should be equal")
+	for _, result := range results {
+		assert.Equal(t, chess.BlackWon, result.Outcome, "white should resign")
+		assert.Equal(t, "resignation", result.Method, "should be equal")
+		assert.Equal(t, result.Round%2 == 1, result.FirstIsWhite, "should be equal")
+		assert.True(t, strings.Contains(result.PGN, "[Event \"engine match\"]"), "should tag the event")
+	}
+
+	_, err = Run(Config{}, blanklogger.NewBlankLogger(), func(Result) {})
+	assert.NotNil(t, err, "should not be nil")
+}
@@ -0,0 +1,73 @@
+package match
+
+import (
+	"fmt"
+	"math"
+)
+
+// z-score of a 95% confidence interval
+const confidence95 = 1.96
+
+// Summary counts the first engine's wins, draws and losses
+type Summary struct {
+	Wins   int
+	Draws  int
+	Losses int
+}
+
+// Add counts a game the first engine scored 1, 0.5 or 0 in
+func (s *Summary) Add(score float64) {
+	switch score {
+	case 1:
+		s.Wins++
+	case 0.5:
+		s.Draws++
+	default:
+		s.Losses++
+	}
+}
+
+// Games returns the number of games counted
+func (s Summary) Games() int {
+	return s.Wins + s.Draws + s.Losses
+}
+
+// Score returns the share of the points the first engine scored
+func (s Summary) Score() float64 {
+	if s.Games() == 0 {
+		return 0.5
+	}
+	return (float64(s.Wins) + float64(s.Draws)/2) / float64(s.Games())
+}
+
+// eloDifference returns the rating difference that expects the score
+func eloDifference(score float64) float64 {
+	return 400 * math.Log10(score/(1-score))
+}
+
+// Elo returns the estimated rating difference of the first engine over the
+// second and the margin of the 95% confidence interval, the difference is
+// infinite when an engine scored every point
+func (s Summary) Elo() (float64, float64) {
+	score := s.Score()
+	if s.Games() == 0 {
+		return 0, math.Inf(1)
+	}
+
+	games := float64(s.Games())
+	variance := (float64(s.Wins)*math.Pow(1-score, 2) +
+		float64(s.Draws)*math.Pow(0.5-score, 2) +
+		float64(s.Losses)*math.Pow(score, 2)) / games
+	margin := confidence95 * math.Sqrt(variance/games)
+
+	low := eloDifference(math.Max(score-margin, 0))
+	high := eloDifference(math.Min(score+margin, 1))
+	return eloDifference(score), (high - low) / 2
+}
+
+// String returns the results and the rating difference, for example
+// +6 =3 -1 (75.0%) elo +191 ± 257
+func (s Summary) String() string {
+	elo, margin := s.Elo()
+	return fmt.Sprintf("+%d =%d -%d (%.1f%%) elo %+.0f ± %.0f", s.Wins, s.Draws, s.Losses, 100*s.Score(), elo, margin)
+}
@@ -0,0 +1,99 @@
+package puzzle
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// the columns of the lichess puzzle database
+const (
+	idColumn = iota
+	fenColumn
+	movesColumn
+	ratingColumn
+	ratingDeviationColumn
+	popularityColumn
+	playsColumn
+	themesColumn
+)
+
+// Puzzle is a tactic from the lichess puzzle database. The FEN is the position
+// before the opponent's move, the first move is the opponent's and the moves
+// after it alternate between the solution and the opponent's replies
+type Puzzle struct {
+	ID     string
+	FEN    string
+	Moves  []string
+	Rating int
+	Themes []string
+}
+
+// Load reads puzzles in the lichess puzzle format, a header row is skipped
+func Load(r io.Reader) ([]Puzzle, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	puzzles := []Puzzle{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if line == 1 && record[idColumn] == "PuzzleId" {
+			continue
+		}
+
+		puzzle, err := parsePuzzle(record)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		puzzles = append(puzzles, puzzle)
+	}
+	return puzzles, nil
+}
+
+// LoadFile reads the puzzles from the file
+func LoadFile(path string) ([]Puzzle, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+func parsePuzzle(record []string) (Puzzle, error) {
+	if len(record) <= ratingColumn {
+		return Puzzle{}, fmt.Errorf("expected at least %d columns: %d", ratingColumn+1, len(record))
+	}
+
+	moves := strings.Fields(record[movesColumn])
+	if len(moves) < 2 {
+		return Puzzle{}, fmt.Errorf("puzzle %s has no solution", record[idColumn])
+	}
+
+	rating, err := strconv.Atoi(record[ratingColumn])
+	if err != nil {
+		return Puzzle{}, fmt.Errorf("puzzle %s has an invalid rating: %s", record[idColumn], record[ratingColumn])
+	}
+
+	themes := []string{}
+	if len(record) > themesColumn {
+		themes = strings.Fields(record[themesColumn])
+	}
+
+	return Puzzle{
+		ID:     record[idColumn],
+		FEN:    record[fenColumn],
+		Moves:  moves,
+		Rating: rating,
+		Themes: themes,
+	}, nil
+}
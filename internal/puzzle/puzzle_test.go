@@ -0,0 +1,125 @@
+package puzzle
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testPuzzles = `PuzzleId,FEN,Moves,Rating,RatingDeviation,Popularity,NbPlays,Themes,GameUrl,OpeningTags
+00sHx,q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17,e8d7 a2e6 d7d8 f7f8,1760,80,83,72,mate mateIn2 middlegame short,https://lichess.org/yyznGmXs/black#34,Italian_Game
+00sJ9,r3r1k1/p4ppp/2p2n2/1p6/3P1qb1/2NQR3/PPB2PP1/R1B3K1 w - - 5 18,e3g3 e8e1 g1h2 e1c1 a1c1 f4h6 h2g1 h6c1,2671,105,87,325,advantage attraction fork middlegame sacrifice veryLong,https://lichess.org/gyFeQsOE#35,French_Defense
+`
+
+func Test_Load_Should_Return_The_Puzzles_When_Given_The_Lichess_Format(t *testing.T) {
+	tests := []struct {
+		csv      string
+		expected int
+	}{
+		{testPuzzles, 2},
+		{strings.SplitN(testPuzzles, "\n", 2)[1], 2},
+		{"", 0},
+	}
+
+	for _, test := range tests {
+		puzzles, err := Load(strings.NewReader(test.csv))
+		assert.Nil(t, err, "should be nil")
+		assert.Equal(t, test.expected, len(puzzles), "should be equal")
+	}
+
+	puzzles, _ := Load(strings.NewReader(testPuzzles))
+	assert.Equal(t, Puzzle{
+		ID:     "00sHx",
+		FEN:    "q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17",
+		Moves:  []string{"e8d7", "a2e6", "d7d8", "f7f8"},
+		Rating: 1760,
+		Themes: []string{"mate", "mateIn2", "middlegame", "short"},
+	}, puzzles[0], "should be equal")
+}
+
+func Test_Load_Should_Return_An_Error_When_A_Row_Is_Invalid(t *testing.T) {
+	tests := []string{
+		"00sHx,q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17",
+		"00sHx,q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17,e8d7,1760",
+		"00sHx,q3k1nr/1pp1nQpp/3p4/1P2p3/4P3/B1PP1b2/B5PP/5K2 b k - 0 17,e8d7 a2e6,hard",
+	}
+
+	for _, test := range tests {
+		_, err := Load(strings.NewReader(test))
+		assert.NotNil(t, err, "should not be nil")
+	}
+}
+
+func Test_NewRating_Should_Change_The_Rating_By_The_Expected_Score(t *testing.T) {
+	tests := []struct {
+		rating       int
+		puzzleRating int
+		solved       bool
+		expected     int
+	}{
+		{1500, 1500, true, 1516},
+		{1500, 1500, false, 1484},
+		{1500, 2300, true, 1532},
+		{1500, 700, false, 1468},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, newRating(test.rating, test.puzzleRating, test.solved), "should be equal")
+	}
+}
+
+func Test_Next_Should_Return_Each_Puzzle_Once_When_The_Player_Has_Not_Seen_Them_All(t *testing.T) {
+	puzzles, _ := Load(strings.NewReader(testPuzzles))
+	store := NewStore(puzzles)
+
+	first, err := store.Next("alice")
+	assert.Nil(t, err, "should be nil")
+	second, err := store.Next("alice")
+	assert.Nil(t, err, "should be nil")
+	assert.NotEqual(t, first.ID, second.ID, "should not be equal")
+
+	_, err = store.Next("alice")
+	assert.Nil(t, err, "should be nil")
+
+	_, err = NewStore(nil).Next("alice")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_Record_Should_Count_The_Puzzle_When_It_Is_Solved_Or_Failed(t *testing.T) {
+	puzzles, _ := Load(strings.NewReader(testPuzzles))
+	store := NewStore(puzzles)
+
+	store.Record("alice", puzzles[0], true)
+	progress := store.Record("alice", puzzles[1], false)
+
+	assert.Equal(t, 1, progress.Solved, "should be equal")
+	assert.Equal(t, 2, progress.Attempted, "should be equal")
+	assert.Equal(t, progress, store.Progress("alice"), "should be equal")
+	assert.Equal(t, Progress{Rating: DefaultRating}, store.Progress("bob"), "should be equal")
+}
+
+func Test_Next_Should_Return_A_Puzzle_Close_To_The_Rating_When_Given_Many_Puzzles(t *testing.T) {
+	puzzles := []Puzzle{}
+	for rating := 3000; rating >= 500; rating -= 10 {
+		puzzles = append(puzzles, Puzzle{ID: strconv.Itoa(rating), Rating: rating})
+	}
+	store := NewStore(puzzles)
+
+	seen := map[string]bool{}
+	for i := 0; i < 20; i++ {
+		puzzle, err := store.Next("alice")
+		assert.Nil(t, err, "should be nil")
+		assert.InDelta(t, DefaultRating, puzzle.Rating, 150, "should be close to the player's rating")
+		assert.False(t, seen[puzzle.ID], "should not be seen again")
+		seen[puzzle.ID] = true
+	}
+
+	for i := 0; i < len(puzzles)-20; i++ {
+		store.Next("alice")
+	}
+	puzzle, err := store.Next("alice")
+	assert.Nil(t, err, "should be nil")
+	assert.InDelta(t, DefaultRating, puzzle.Rating, 50, "should start again once every puzzle was seen")
+}
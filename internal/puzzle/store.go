@@ -0,0 +1,148 @@
+package puzzle
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRating is the puzzle rating of a player who has not tried a puzzle
+	DefaultRating = 1500
+
+	// how much a rating can change after one puzzle
+	kFactor = 32
+
+	// the next puzzle is picked at random from the unseen puzzles closest to
+	// the player's rating
+	closestPuzzles = 10
+)
+
+// Progress is a player's puzzle rating and the puzzles they have tried
+type Progress struct {
+	Rating    int
+	Solved    int
+	Attempted int
+}
+
+type playerProgress struct {
+	Progress
+	seen map[string]bool
+}
+
+// Store holds the puzzles and each player's progress, the progress is kept
+// while the server runs
+type Store struct {
+	// puzzles are sorted by rating so the puzzles close to a player's rating
+	// are found without looking at the others
+	puzzles []Puzzle
+	players map[string]*playerProgress
+	mutex   sync.Mutex
+	random  *rand.Rand
+}
+
+func NewStore(puzzles []Puzzle) *Store {
+	sorted := append([]Puzzle{}, puzzles...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Rating < sorted[j].Rating
+	})
+	return &Store{
+		puzzles: sorted,
+		players: map[string]*playerProgress{},
+		random:  rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+func (s *Store) player(name string) *playerProgress {
+	p, ok := s.players[name]
+	if !ok {
+		p = &playerProgress{
+			Progress: Progress{Rating: DefaultRating},
+			seen:     map[string]bool{},
+		}
+		s.players[name] = p
+	}
+	return p
+}
+
+// Progress returns the player's rating and the puzzles they have tried
+func (s *Store) Progress(name string) Progress {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.player(name).Progress
+}
+
+// Next returns a puzzle close to the player's rating that they have not seen,
+// the puzzles are seen again once the player has seen them all
+func (s *Store) Next(name string) (Puzzle, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if len(s.puzzles) == 0 {
+		return Puzzle{}, errors.New("there are no puzzles")
+	}
+
+	p := s.player(name)
+	closest := s.closestUnseen(p)
+	if len(closest) == 0 {
+		p.seen = map[string]bool{}
+		closest = s.closestUnseen(p)
+	}
+
+	puzzle := closest[s.random.Intn(len(closest))]
+	p.seen[puzzle.ID] = true
+	return puzzle, nil
+}
+
+// closestUnseen returns the puzzles the player has not seen closest to the
+// player's rating, it starts from the puzzles with the player's rating and
+// works outwards
+func (s *Store) closestUnseen(p *playerProgress) []Puzzle {
+	closest := []Puzzle{}
+	above := sort.Search(len(s.puzzles), func(i int) bool {
+		return s.puzzles[i].Rating >= p.Rating
+	})
+	below := above - 1
+	for len(closest) < closestPuzzles && (below >= 0 || above < len(s.puzzles)) {
+		var puzzle Puzzle
+		if below < 0 || (above < len(s.puzzles) && s.puzzles[above].Rating-p.Rating < p.Rating-s.puzzles[below].Rating) {
+			puzzle = s.puzzles[above]
+			above++
+		} else {
+			puzzle = s.puzzles[below]
+			below--
+		}
+		if !p.seen[puzzle.ID] {
+			closest = append(closest, puzzle)
+		}
+	}
+	return closest
+}
+
+// Record updates the player's rating after they solved or failed the puzzle
+// and returns their progress
+func (s *Store) Record(name string, puzzle Puzzle, solved bool) Progress {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p := s.player(name)
+	p.Rating = newRating(p.Rating, puzzle.Rating, solved)
+	p.Attempted++
+	if solved {
+		p.Solved++
+	}
+	return p.Progress
+}
+
+// newRating returns the player's Elo rating after playing against the puzzle
+func newRating(rating, puzzleRating int, solved bool) int {
+	expected := 1 / (1 + math.Pow(10, float64(puzzleRating-rating)/400))
+	score := 0.0
+	if solved {
+		score = 1
+	}
+	return rating + int(math.Round(kFactor*(score-expected)))
+}
@@ -0,0 +1,249 @@
+package uci
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+const (
+	// how long the engine has to answer a command on top of the time it was
+	// given to search
+	responseTimeout = 10 * time.Second
+	// how long the engine has to search each ply when it is only limited by
+	// depth
+	depthTimeout = 5 * time.Second
+	// how long the engine searches when it is not given a limit
+	defaultMoveTime = time.Second
+)
+
+// Config is how the engine is started and how long it searches
+type Config struct {
+	// Path is the path to the engine binary
+	Path string
+	// MoveTime is how long the engine searches each move, the engine searches
+	// until Depth is reached when it is zero
+	MoveTime time.Duration
+	// Depth is how deep the engine searches each move, the depth is not
+	// limited when it is zero
+	Depth int
+	// Timeout is how long the engine has to answer with its move, when it is
+	// zero the engine has the move time or a time that grows with the depth
+	Timeout time.Duration
+	// PoolSize is the most engine processes that run at the same time
+	PoolSize int
+}
+
+// goCommand returns the command that starts a search limited by the config
+func (c Config) goCommand() string {
+	command := "go"
+	if c.MoveTime > 0 {
+		command += fmt.Sprintf(" movetime %d", c.MoveTime.Milliseconds())
+	}
+	if c.Depth > 0 {
+		command += fmt.Sprintf(" depth %d", c.Depth)
+	}
+	if command == "go" {
+		command += fmt.Sprintf(" movetime %d", defaultMoveTime.Milliseconds())
+	}
+	return command
+}
+
+// searchTimeout returns how long the engine has to answer the go command
+func (c Config) searchTimeout() time.Duration {
+	switch {
+	case c.Timeout > 0:
+		return c.Timeout
+	case c.MoveTime > 0:
+		return c.MoveTime + responseTimeout
+	case c.Depth > 0:
+		return time.Duration(c.Depth)*depthTimeout + responseTimeout
+	default:
+		return defaultMoveTime + responseTimeout
+	}
+}
+
+// Engine is a running UCI engine process that is sent commands on its stdin and
+// answers on its stdout
+type Engine struct {
+	config  Config
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	lines   chan string
+	options map[string]bool
+	skill   int
+}
+
+// NewEngine starts the engine and waits for it to be ready
+func NewEngine(config Config) (*Engine, error) {
+	cmd := exec.Command(config.Path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	e := &Engine{
+		config:  config,
+		cmd:     cmd,
+		stdin:   stdin,
+		lines:   make(chan string),
+		options: map[string]bool{},
+		skill:   -1,
+	}
+
+	// the lines are read in the background so reading them can time out
+	go func() {
+		scanner := bufio.NewScanner(stdout)
+		for scanner.Scan() {
+			e.lines <- scanner.Text()
+		}
+		close(e.lines)
+	}()
+
+	if err := e.send("uci"); err != nil {
+		e.Close()
+		return nil, err
+	}
+	err = e.readUntil("uciok", func(line string) {
+		// option name Skill Level type spin default 20 min 0 max 20
+		if strings.HasPrefix(line, "option name ") {
+			name := strings.TrimPrefix(line, "option name ")
+			if i := strings.Index(name, " type "); i >= 0 {
+				e.options[name[:i]] = true
+			}
+		}
+	})
+	if err != nil {
+		e.Close()
+		return nil, err
+	}
+
+	if err := e.isReady(); err != nil {
+		e.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *Engine) send(command string) error {
+	_, err := fmt.Fprintf(e.stdin, "%s\n", command)
+	return err
+}
+
+// readLine reads lines until a line starting with the prefix is read or the
+// timeout passes and returns that line, every line read before it is passed to
+// handle
+func (e *Engine) readLine(prefix string, timeout time.Duration, handle func(string)) (string, error) {
+	expired := time.After(timeout)
+	for {
+		select {
+		case line, ok := <-e.lines:
+			if !ok {
+				return "", errors.New("engine stopped")
+			}
+			if strings.HasPrefix(line, prefix) {
+				return line, nil
+			}
+			if handle != nil {
+				handle(line)
+			}
+		case <-expired:
+			return "", fmt.Errorf("engine did not answer with %s in time", prefix)
+		}
+	}
+}
+
+func (e *Engine) readUntil(prefix string, handle func(string)) error {
+	_, err := e.readLine(prefix, responseTimeout, handle)
+	return err
+}
+
+func (e *Engine) isReady() error {
+	if err := e.send("isready"); err != nil {
+		return err
+	}
+	return e.readUntil("readyok", nil)
+}
+
+// HasOption returns true when the engine said it has the option
+func (e *Engine) HasOption(name string) bool {
+	return e.options[name]
+}
+
+// SetSkillLevel sets the engine's skill level when the engine has the Skill
+// Level option
+func (e *Engine) SetSkillLevel(skill int) error {
+	if !e.HasOption("Skill Level") || skill == e.skill {
+		return nil
+	}
+	if err := e.send(fmt.Sprintf("setoption name Skill Level value %d", skill)); err != nil {
+		return err
+	}
+	if err := e.isReady(); err != nil {
+		return err
+	}
+	e.skill = skill
+	return nil
+}
+
+// BestMove searches the position given as a FEN and returns the best move in
+// UCI notation
+func (e *Engine) BestMove(fen string) (string, error) {
+	for _, command := range []string{"ucinewgame", "position fen " + fen} {
+		if err := e.send(command); err != nil {
+			return "", err
+		}
+	}
+	if err := e.isReady(); err != nil {
+		return "", err
+	}
+	if err := e.send(e.config.goCommand()); err != nil {
+		return "", err
+	}
+
+	// bestmove e2e4 ponder e7e5
+	line, err := e.readLine("bestmove", e.config.searchTimeout(), nil)
+	if err != nil {
+		return "", err
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[1] == "(none)" || fields[1] == "0000" {
+		return "", errors.New("engine has no move")
+	}
+	return fields[1], nil
+}
+
+// Close asks the engine to quit and stops the process
+func (e *Engine) Close() error {
+	e.send("quit")
+	e.stdin.Close()
+
+	// lines the engine writes while quitting are not read by anyone
+	go func() {
+		for range e.lines {
+		}
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- e.cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(time.Second):
+		e.cmd.Process.Kill()
+		return <-done
+	}
+}
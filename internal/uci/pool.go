@@ -0,0 +1,186 @@
+package uci
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/n7down/ssh-chess/internal/logger"
+	"github.com/notnil/chess"
+)
+
+const (
+	defaultPoolSize = 2
+
+	minLevel = 1
+	maxLevel = 5
+
+	// the highest skill level of engines with the Skill Level option such as
+	// stockfish
+	maxSkillLevel = 20
+)
+
+// errPoolClosed is returned for a move asked for after the pool is closed
+var errPoolClosed = errors.New("the uci engine pool is closed")
+
+// Pool shares engine processes between games. Engines are started when they
+// are first needed and an engine that fails is stopped and replaced
+type Pool struct {
+	config  Config
+	engines chan *Engine
+	slots   chan struct{}
+	mutex   sync.Mutex
+	started int
+	running map[*Engine]struct{}
+	closed  bool
+	logger  logger.Logger
+}
+
+// NewPool returns a pool that runs up to config.PoolSize engines
+func NewPool(config Config, logger logger.Logger) *Pool {
+	if config.PoolSize <= 0 {
+		config.PoolSize = defaultPoolSize
+	}
+	return &Pool{
+		config:  config,
+		engines: make(chan *Engine, config.PoolSize),
+		slots:   make(chan struct{}, config.PoolSize),
+		running: map[*Engine]struct{}{},
+		logger:  logger,
+	}
+}
+
+// get returns an idle engine, an engine is started when there are none idle
+// and the pool is not full otherwise get waits for an engine to be put back
+func (p *Pool) get() (*Engine, error) {
+	p.mutex.Lock()
+	closed := p.closed
+	p.mutex.Unlock()
+	if closed {
+		return nil, errPoolClosed
+	}
+
+	select {
+	case e := <-p.engines:
+		return e, nil
+	default:
+	}
+
+	select {
+	case e := <-p.engines:
+		return e, nil
+	case p.slots <- struct{}{}:
+		e, err := NewEngine(p.config)
+		if err != nil {
+			<-p.slots
+			return nil, err
+		}
+		p.mutex.Lock()
+		if p.closed {
+			p.mutex.Unlock()
+			e.Close()
+			<-p.slots
+			return nil, errPoolClosed
+		}
+		p.started++
+		p.running[e] = struct{}{}
+		p.mutex.Unlock()
+		p.logger.Debug(fmt.Sprintf("started uci engine %s", p.config.Path))
+		return e, nil
+	}
+}
+
+// put gives the engine back to the pool, an engine that failed is stopped to
+// make room for a new one
+func (p *Pool) put(e *Engine, err error) {
+	p.mutex.Lock()
+	if p.closed {
+		// the engine was stopped when the pool was closed
+		p.mutex.Unlock()
+		<-p.slots
+		return
+	}
+	if err == nil {
+		p.engines <- e
+		p.mutex.Unlock()
+		return
+	}
+	delete(p.running, e)
+	p.mutex.Unlock()
+
+	p.logger.Debug(fmt.Sprintf("stopping uci engine: %v", err.Error()))
+	e.Close()
+	<-p.slots
+}
+
+// Close stops every engine the pool started including the ones searching for a
+// move, their searches fail and no more engines are started
+func (p *Pool) Close() {
+	p.mutex.Lock()
+	p.closed = true
+	running := p.running
+	p.running = map[*Engine]struct{}{}
+	p.mutex.Unlock()
+
+	for e := range running {
+		e.Close()
+	}
+
+	// the idle engines were stopped with the others
+	for {
+		select {
+		case <-p.engines:
+			<-p.slots
+		default:
+			return
+		}
+	}
+}
+
+// Bot returns a bot that plays at the level from 1 to 5 using the pool's
+// engines, the level sets the engine's skill level when it has one
+func (p *Pool) Bot(level int) *Bot {
+	if level < minLevel {
+		level = minLevel
+	}
+	if level > maxLevel {
+		level = maxLevel
+	}
+	return &Bot{
+		pool:  p,
+		skill: (level - minLevel) * maxSkillLevel / (maxLevel - minLevel),
+	}
+}
+
+// Bot plays the move a UCI engine from the pool chooses
+type Bot struct {
+	pool  *Pool
+	skill int
+}
+
+// Move returns the engine's best move in the position
+func (b *Bot) Move(position *chess.Position) (*chess.Move, error) {
+	e, err := b.pool.get()
+	if err != nil {
+		return nil, err
+	}
+
+	bestMove, err := b.bestMove(e, position)
+	b.pool.put(e, err)
+	if err != nil {
+		return nil, err
+	}
+
+	move, err := chess.UCINotation{}.Decode(position, bestMove)
+	if err != nil {
+		return nil, fmt.Errorf("engine played an invalid move %s: %v", bestMove, err)
+	}
+	return move, nil
+}
+
+func (b *Bot) bestMove(e *Engine, position *chess.Position) (string, error) {
+	if err := e.SetSkillLevel(b.skill); err != nil {
+		return "", err
+	}
+	return e.BestMove(position.String())
+}
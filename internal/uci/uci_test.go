@@ -0,0 +1,227 @@
+package uci
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/n7down/ssh-chess/internal/logger/blanklogger"
+	"github.com/notnil/chess"
+	"github.com/stretchr/testify/assert"
+)
+
+// the test binary runs as a fake engine when the variable is set
+const fakeEngineEnv = "UCI_FAKE_ENGINE"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakeEngineEnv) != "" {
+		runFakeEngine()
+		os.Exit(0)
+	}
+	os.Setenv(fakeEngineEnv, "1")
+	os.Exit(m.Run())
+}
+
+// runFakeEngine speaks enough UCI to play the first legal move in UCI order,
+// the skill level is written as an info line before the best move
+func runFakeEngine() {
+	position := chess.NewGame().Position()
+	skill := 20
+
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "uci":
+			fmt.Println("id name fake")
+			fmt.Println("option name Skill Level type spin default 20 min 0 max 20")
+			fmt.Println("uciok")
+		case "isready":
+			fmt.Println("readyok")
+		case "setoption":
+			fmt.Sscanf(strings.Join(fields[1:], " "), "name Skill Level value %d", &skill)
+		case "position":
+			if len(fields) > 2 && fields[1] == "fen" {
+				fen, err := chess.FEN(strings.Join(fields[2:], " "))
+				if err == nil {
+					position = chess.NewGame(fen).Position()
+				}
+			}
+		case "go":
+			moves := []string{}
+			for _, move := range position.ValidMoves() {
+				moves = append(moves, chess.UCINotation{}.Encode(position, move))
+			}
+			sort.Strings(moves)
+			fmt.Printf("info string skill %d %s\n", skill, strings.Join(fields, " "))
+			if len(moves) == 0 {
+				fmt.Println("bestmove (none)")
+			} else {
+				fmt.Printf("bestmove %s\n", moves[0])
+			}
+		case "quit":
+			return
+		}
+	}
+}
+
+func newTestPool(size int) *Pool {
+	return NewPool(Config{Path: os.Args[0], MoveTime: 10 * time.Millisecond, PoolSize: size}, blanklogger.NewBlankLogger())
+}
+
+func Test_GoCommand_Should_Limit_The_Search_When_Configured(t *testing.T) {
+	tests := []struct {
+		config   Config
+		expected string
+	}{
+		{Config{MoveTime: 500 * time.Millisecond}, "go movetime 500"},
+		{Config{Depth: 12}, "go depth 12"},
+		{Config{MoveTime: time.Second, Depth: 8}, "go movetime 1000 depth 8"},
+		{Config{}, "go movetime 1000"},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.config.goCommand(), "should be equal")
+	}
+}
+
+func Test_SearchTimeout_Should_Scale_With_The_Depth_When_There_Is_No_Move_Time(t *testing.T) {
+	tests := []struct {
+		config   Config
+		expected time.Duration
+	}{
+		{Config{MoveTime: 500 * time.Millisecond}, 10500 * time.Millisecond},
+		{Config{Depth: 4}, 30 * time.Second},
+		{Config{Depth: 20}, 110 * time.Second},
+		{Config{Depth: 20, Timeout: time.Minute}, time.Minute},
+		{Config{}, 11 * time.Second},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.config.searchTimeout(), "should be equal")
+	}
+}
+
+func Test_NewEngine_Should_Read_The_Engines_Options_When_Started(t *testing.T) {
+	e, err := NewEngine(Config{Path: os.Args[0]})
+	assert.Nil(t, err, "should be nil")
+	defer e.Close()
+
+	assert.True(t, e.HasOption("Skill Level"), "should have the skill level option")
+	assert.False(t, e.HasOption("Hash"), "should not have the hash option")
+}
+
+func Test_NewEngine_Should_Return_An_Error_When_The_Path_Does_Not_Exist(t *testing.T) {
+	_, err := NewEngine(Config{Path: "/does/not/exist"})
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_BestMove_Should_Return_The_Engines_Move(t *testing.T) {
+	e, err := NewEngine(Config{Path: os.Args[0]})
+	assert.Nil(t, err, "should be nil")
+	defer e.Close()
+
+	move, err := e.BestMove("8/8/8/8/8/8/4K3/k6R b - - 0 40")
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, "a1a2", move, "should be equal")
+
+	_, err = e.BestMove("k7/1Q6/1K6/8/8/8/8/8 b - - 0 1")
+	assert.NotNil(t, err, "should not be nil")
+}
+
+func Test_Move_Should_Decode_The_Engines_Move_When_Playing_As_A_Bot(t *testing.T) {
+	pool := newTestPool(1)
+	defer pool.Close()
+
+	position := chess.NewGame().Position()
+	move, err := pool.Bot(3).Move(position)
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, chess.A2, move.S1(), "should be equal")
+	assert.Equal(t, chess.A3, move.S2(), "should be equal")
+}
+
+func Test_Move_Should_Reuse_The_Engines_When_Games_Share_A_Pool(t *testing.T) {
+	pool := newTestPool(2)
+	defer pool.Close()
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func(level int) {
+			defer wg.Done()
+			_, err := pool.Bot(level).Move(chess.NewGame().Position())
+			assert.Nil(t, err, "should be nil")
+		}(i%5 + 1)
+	}
+	wg.Wait()
+
+	assert.True(t, pool.started <= 2, "should start at most two engines")
+	assert.Equal(t, pool.started, len(pool.engines), "should be equal")
+}
+
+func Test_Move_Should_Replace_The_Engine_When_It_Stops(t *testing.T) {
+	pool := newTestPool(1)
+	defer pool.Close()
+
+	bot := pool.Bot(5)
+	_, err := bot.Move(chess.NewGame().Position())
+	assert.Nil(t, err, "should be nil")
+
+	e := <-pool.engines
+	e.cmd.Process.Kill()
+	pool.engines <- e
+
+	_, err = bot.Move(chess.NewGame().Position())
+	assert.NotNil(t, err, "should not be nil")
+
+	_, err = bot.Move(chess.NewGame().Position())
+	assert.Nil(t, err, "should be nil")
+	assert.Equal(t, 2, pool.started, "should be equal")
+}
+
+func Test_Close_Should_Stop_Every_Engine_When_Some_Are_Searching(t *testing.T) {
+	pool := newTestPool(2)
+	idle, err := pool.get()
+	assert.Nil(t, err, "should be nil")
+	searching, err := pool.get()
+	assert.Nil(t, err, "should be nil")
+	pool.put(idle, nil)
+
+	pool.Close()
+	assert.NotNil(t, idle.cmd.ProcessState, "should stop the idle engine")
+	assert.NotNil(t, searching.cmd.ProcessState, "should stop the searching engine")
+
+	pool.put(searching, nil)
+	assert.Equal(t, 0, len(pool.engines), "should be equal")
+	assert.Equal(t, 0, len(pool.slots), "should be equal")
+
+	_, err = pool.Bot(1).Move(chess.NewGame().Position())
+	assert.Equal(t, errPoolClosed, err, "should be equal")
+}
+
+func Test_Bot_Should_Map_The_Level_To_The_Skill_Level(t *testing.T) {
+	pool := newTestPool(1)
+
+	tests := []struct {
+		level    int
+		expected int
+	}{
+		{0, 0},
+		{1, 0},
+		{3, 10},
+		{5, 20},
+		{9, 20},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, pool.Bot(test.level).skill, "should be equal")
+	}
+}